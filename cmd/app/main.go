@@ -6,16 +6,29 @@ import (
 	"net/http"
 
 	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/bundle"
 	"github.com/Mickey327/rcsp-backend/internal/app/cart"
 	"github.com/Mickey327/rcsp-backend/internal/app/category"
 	"github.com/Mickey327/rcsp-backend/internal/app/comment"
 	"github.com/Mickey327/rcsp-backend/internal/app/company"
 	appConfig "github.com/Mickey327/rcsp-backend/internal/app/config"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+	"github.com/Mickey327/rcsp-backend/internal/app/guestcart"
+	"github.com/Mickey327/rcsp-backend/internal/app/idempotency"
 	"github.com/Mickey327/rcsp-backend/internal/app/order"
 	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/app/payment"
 	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/Mickey327/rcsp-backend/internal/app/promo"
+	"github.com/Mickey327/rcsp-backend/internal/app/refund"
+	"github.com/Mickey327/rcsp-backend/internal/app/reminder"
+	"github.com/Mickey327/rcsp-backend/internal/app/reservation"
+	"github.com/Mickey327/rcsp-backend/internal/app/sale"
+	"github.com/Mickey327/rcsp-backend/internal/app/scheduler"
+	"github.com/Mickey327/rcsp-backend/internal/app/tax"
 	"github.com/Mickey327/rcsp-backend/internal/app/user"
 	"github.com/Mickey327/rcsp-backend/internal/app/validator"
+	"github.com/Mickey327/rcsp-backend/internal/app/wishlist"
 	dbConfig "github.com/Mickey327/rcsp-backend/internal/db/config"
 	"github.com/Mickey327/rcsp-backend/internal/db/repository/postgres"
 	"github.com/golang-jwt/jwt/v4"
@@ -46,12 +59,34 @@ func main() {
 		TokenLookup: "header:Authorization:Bearer ,cookie:jwt",
 	})
 
+	idempotencyService := idempotency.NewService(idempotency.NewRepository(db), appConf.IdempotencyKeyTTL,
+		appConf.IdempotencyLeaseTTL)
+	idempotent := idempotencyService.Middleware
+	scheduler.Every(ctx, appConf.IdempotencyCleanInterval, "idempotency keys cleanup", idempotencyService.CollectGarbage)
+
+	var ratesSource currency.Source
+	switch appConf.CurrencyRatesSource {
+	case "file":
+		ratesSource = currency.NewFileSource(appConf.CurrencyRatesPath)
+	case "http":
+		ratesSource = currency.NewHTTPSource(appConf.CurrencyRatesURL)
+	default:
+		log.Fatalf("unknown currency rates source: %s", appConf.CurrencyRatesSource)
+	}
+	converter := currency.NewConverter(ratesSource, appConf.CurrencyRatesTTL)
+	e.Use(currency.Middleware)
+	currencyHandler := currency.NewHandler(converter)
+	e.GET("/api/currency", currencyHandler.ReadRates)
+
 	categoryHandler := category.NewHandler(category.NewService(category.NewRepository(db)))
 	e.GET("/api/category/:id", categoryHandler.Read)
 	e.GET("/api/category", categoryHandler.ReadAll)
 	e.DELETE("/api/category/:id", categoryHandler.Delete, jwtMiddleware)
 	e.POST("/api/category", categoryHandler.Create, jwtMiddleware)
 	e.PUT("/api/category", categoryHandler.Update, jwtMiddleware)
+	e.GET("/api/category/deleted", categoryHandler.ReadDeleted, jwtMiddleware)
+	e.POST("/api/category/:id/restore", categoryHandler.Restore, jwtMiddleware)
+	e.DELETE("/api/category/:id/purge", categoryHandler.Purge, jwtMiddleware)
 
 	companyHandler := company.NewHandler(company.NewService(company.NewRepository(db)))
 	e.GET("/api/company/:id", companyHandler.Read)
@@ -59,31 +94,157 @@ func main() {
 	e.DELETE("/api/company/:id", companyHandler.Delete, jwtMiddleware)
 	e.POST("/api/company", companyHandler.Create, jwtMiddleware)
 	e.PUT("/api/company", companyHandler.Update, jwtMiddleware)
+	e.GET("/api/company/deleted", companyHandler.ReadDeleted, jwtMiddleware)
+	e.POST("/api/company/:id/restore", companyHandler.Restore, jwtMiddleware)
+	e.DELETE("/api/company/:id/purge", companyHandler.Purge, jwtMiddleware)
 
-	productHandler := product.NewHandler(product.NewService(product.NewRepository(db)))
+	productHandler := product.NewHandler(product.NewService(product.NewRepository(db), converter))
 	e.GET("/api/product/:id", productHandler.Read)
 	e.GET("/api/product", productHandler.ReadAll) // ?categoryID&companyID
+	e.GET("/api/product/:id/prices", productHandler.ReadPriceHistory)
 	e.DELETE("/api/product/:id", productHandler.Delete, jwtMiddleware)
 	e.POST("/api/product", productHandler.Create, jwtMiddleware)
 	e.PUT("/api/product", productHandler.Update, jwtMiddleware)
+	e.GET("/api/product/deleted", productHandler.ReadDeleted, jwtMiddleware)
+	e.POST("/api/product/:id/restore", productHandler.Restore, jwtMiddleware)
+	e.DELETE("/api/product/:id/purge", productHandler.Purge, jwtMiddleware)
+
+	saleHandler := sale.NewHandler(sale.NewService(sale.NewRepository(db)))
+	e.GET("/api/sale/:id", saleHandler.Read)
+	e.GET("/api/sale", saleHandler.ReadNotEnded)
+	e.GET("/api/sale/all", saleHandler.ReadAll, jwtMiddleware)
+	e.POST("/api/sale", saleHandler.Create, jwtMiddleware)
+	e.PUT("/api/sale", saleHandler.Update, jwtMiddleware)
+	e.DELETE("/api/sale/:id", saleHandler.Delete, jwtMiddleware)
+
+	bundleHandler := bundle.NewHandler(bundle.NewService(bundle.NewRepository(db), converter, db))
+	e.GET("/api/bundle/:id", bundleHandler.Read)
+	e.GET("/api/bundle", bundleHandler.ReadAll)
+	e.POST("/api/bundle", bundleHandler.Create, jwtMiddleware)
+	e.DELETE("/api/bundle/:id", bundleHandler.Delete, jwtMiddleware)
+
+	promoService := promo.NewService(promo.NewRepository(db), order.NewRepository(db), db)
+	promoHandler := promo.NewHandler(promoService)
+	e.GET("/api/promo/:id", promoHandler.Read, jwtMiddleware)
+	e.GET("/api/promo", promoHandler.ReadAll, jwtMiddleware)
+	e.POST("/api/promo", promoHandler.Create, jwtMiddleware)
+	e.DELETE("/api/promo/:id", promoHandler.Delete, jwtMiddleware)
+
+	if appConf.GuestCartSecret == "" {
+		log.Fatal("guest cart secret is empty")
+	}
+	guestCartService := guestcart.NewService(guestcart.NewRepository(db), order.NewRepository(db), promoService, converter, db,
+		appConf.GuestCartSecret, appConf.GuestCartTTL)
+	guestCartHandler := guestcart.NewHandler(guestCartService)
+	e.GET("/api/guest/cart", guestCartHandler.Read)
+	e.POST("/api/guest/cart", guestCartHandler.UpdateItem)   // ?productID
+	e.DELETE("/api/guest/cart", guestCartHandler.DeleteItem) // ?productID
+	scheduler.Every(ctx, appConf.GuestCartGCInterval, "guest cart gc", guestCartService.CollectGarbage)
+
+	reservationService := reservation.NewService(reservation.NewRepository(db), db, appConf.ReservationTTL)
+	scheduler.Every(ctx, appConf.ReservationSweepInterval, "stock reservation sweeper", reservationService.Sweep)
+
+	wishlistService := wishlist.NewService(wishlist.NewRepository(db), converter)
+	wishlistHandler := wishlist.NewHandler(wishlistService)
+	e.GET("/api/wishlist", wishlistHandler.Read, jwtMiddleware)
+	e.POST("/api/wishlist", wishlistHandler.Add, jwtMiddleware)
+	e.DELETE("/api/wishlist/:productID", wishlistHandler.Remove, jwtMiddleware)
+	e.POST("/api/wishlist/share", wishlistHandler.Share, jwtMiddleware)
+	e.DELETE("/api/wishlist/share", wishlistHandler.Unshare, jwtMiddleware)
+	e.GET("/api/wishlist/shared/:token", wishlistHandler.ReadShared)
+	scheduler.Every(ctx, appConf.WishlistAlertInterval, "wishlist alerts", wishlistService.SendAlerts)
 
 	valid := validator.NewValidator()
-	userHandler := user.NewHandler(user.NewService(user.NewRepository(db)))
+	userHandler := user.NewHandler(user.NewService(user.NewRepository(db), guestCartService))
 	e.Validator = valid
 	e.POST("/api/register", userHandler.Register)
 	e.POST("/api/login", userHandler.Login)
 	e.GET("/api/logout", userHandler.Logout)
 	e.GET("/api/user", userHandler.GetAuthenticatedUser, jwtMiddleware)
+	e.PUT("/api/user/region", userHandler.UpdateRegion, jwtMiddleware)
 
-	cartHandler := cart.NewHandler(cart.NewService(order.NewRepository(db), orderItem.NewRepository(db)))
-	e.POST("/api/cart", cartHandler.UpdateCart, jwtMiddleware)       // ?orderID&productID
-	e.DELETE("/api/cart", cartHandler.RemoveFromCart, jwtMiddleware) // ?orderID&productID
+	taxService := tax.NewService(tax.NewRepository(db), appConf.TaxInclusive)
+	taxHandler := tax.NewHandler(taxService)
+	e.GET("/api/tax", taxHandler.ReadAll, jwtMiddleware)
+	e.POST("/api/tax", taxHandler.Create, jwtMiddleware)
+	e.DELETE("/api/tax/:id", taxHandler.Delete, jwtMiddleware)
 
-	orderHandler := order.NewHandler(order.NewService(order.NewRepository(db)))
+	orderService := order.NewService(order.NewRepository(db), db, promoService, taxService, converter, reservationService)
+	orderHandler := order.NewHandler(orderService)
 	e.GET("/api/order", orderHandler.ReadCurrentUserArrangingOrder, jwtMiddleware)
+	e.GET("/api/cart/validate", orderHandler.ValidateCart, jwtMiddleware)
 	e.POST("/api/order", orderHandler.Create, jwtMiddleware)
 	e.GET("/api/order/:id", orderHandler.ReadByIdEager, jwtMiddleware)
-	e.PUT("/api/order", orderHandler.Update, jwtMiddleware)
+	e.PUT("/api/order", orderHandler.Update, jwtMiddleware, idempotent)
+	e.GET("/api/order/:id/history", orderHandler.ReadStatusHistory, jwtMiddleware)
+	e.POST("/api/order/:id/cancel", orderHandler.Cancel, jwtMiddleware, idempotent)
+	e.GET("/api/orders/me", orderHandler.ReadMine, jwtMiddleware)        // ?status&from&to&page&limit
+	e.GET("/api/admin/orders", orderHandler.AdminReadAll, jwtMiddleware) // ?status&email&from&to&minTotal&maxTotal&sort&order&page&limit
+	e.GET("/api/admin/orders/:id", orderHandler.AdminRead, jwtMiddleware)
+	e.PUT("/api/admin/orders/status", orderHandler.AdminBulkUpdate, jwtMiddleware)
+	e.GET("/api/gift/:token", orderHandler.OpenGift)
+	scheduler.Every(ctx, appConf.GiftDeliveryInterval, "gift delivery", orderService.SendGifts)
+
+	cartHandler := cart.NewHandler(cart.NewService(order.NewRepository(db), orderItem.NewRepository(db), product.NewRepository(db),
+		bundle.NewRepository(db), promoService, converter, reservationService, orderService, db))
+	e.POST("/api/cart", cartHandler.UpdateCart, jwtMiddleware, idempotent)       // ?orderID&productID
+	e.DELETE("/api/cart", cartHandler.RemoveFromCart, jwtMiddleware, idempotent) // ?orderID&productID
+	e.POST("/api/cart/promo", cartHandler.ApplyPromoCode, jwtMiddleware, idempotent)
+	e.DELETE("/api/cart/promo", cartHandler.RemovePromoCode, jwtMiddleware)
+	e.GET("/api/carts", cartHandler.ReadNamedCarts, jwtMiddleware)
+	e.POST("/api/carts", cartHandler.CreateNamedCart, jwtMiddleware)
+	e.DELETE("/api/carts/:id", cartHandler.DeleteNamedCart, jwtMiddleware)
+	e.POST("/api/carts/:id/move", cartHandler.MoveToNamedCart, jwtMiddleware, idempotent) // ?productID
+	e.POST("/api/carts/:id/restore", cartHandler.MoveToCart, jwtMiddleware, idempotent)   // ?productID
+	e.POST("/api/cart/save", cartHandler.SaveForLater, jwtMiddleware, idempotent)         // ?productID
+	e.POST("/api/order/:id/reorder", cartHandler.Reorder, jwtMiddleware, idempotent)
+	e.POST("/api/cart/bundle", cartHandler.AddBundle, jwtMiddleware, idempotent)      // ?bundleID&quantity
+	e.DELETE("/api/cart/bundle", cartHandler.RemoveBundle, jwtMiddleware, idempotent) // ?bundleID
+
+	if appConf.CartReminderSecret == "" {
+		log.Fatal("cart reminder secret is empty")
+	}
+	reminderService := reminder.NewService(reminder.NewRepository(db), order.NewRepository(db), appConf.CartReminderSecret,
+		appConf.CartReminderIdle, appConf.CartReminderWindow)
+	reminderHandler := reminder.NewHandler(reminderService)
+	e.GET("/api/cart/reminders/unsubscribe", reminderHandler.ConfirmUnsubscribe) // ?token
+	e.POST("/api/cart/reminders/unsubscribe", reminderHandler.Unsubscribe)       // token
+	scheduler.Every(ctx, appConf.CartReminderInterval, "abandoned cart reminders", reminderService.SendReminders)
+
+	// anyone knowing the secret can mark orders paid, so it's required for every provider
+	if appConf.PaymentWebhookSecret == "" {
+		log.Fatal("payment webhook secret is empty")
+	}
+
+	var paymentProvider payment.Provider
+	var mockProvider *payment.MockProvider
+	switch {
+	case appConf.PaymentProvider == payment.MockProviderName && appConf.DevMode:
+		mockProvider = payment.NewMockProvider(appConf.PaymentWebhookSecret, appConf.ApiHost+":"+appConf.ApiPort)
+		paymentProvider = mockProvider
+	case appConf.PaymentProvider == payment.MockProviderName:
+		log.Fatal("mock payment provider is available only in dev mode")
+	default:
+		log.Fatalf("unknown payment provider: %s", appConf.PaymentProvider)
+	}
+
+	paymentService := payment.NewService(payment.NewRepository(db), order.NewRepository(db), orderService, paymentProvider, db)
+	paymentHandler := payment.NewHandler(paymentService)
+	e.POST("/api/order/:id/pay", paymentHandler.Create, jwtMiddleware, idempotent)
+	e.GET("/api/order/:id/payments", paymentHandler.ReadByOrderID, jwtMiddleware)
+	e.POST("/api/payments/webhook", paymentHandler.Webhook)
+	scheduler.Every(ctx, appConf.PaymentRefundRetryInterval, "payment refunds retry", paymentService.RetryRefunds)
+	if mockProvider != nil {
+		mockHandler := payment.NewMockHandler(mockProvider, paymentService)
+		e.GET("/api/payments/mock/:intentID", mockHandler.Pay) // ?fail
+	}
+
+	refundHandler := refund.NewHandler(refund.NewService(refund.NewRepository(db), order.NewRepository(db), orderService, paymentService, db))
+	e.POST("/api/order/:id/refund", refundHandler.Create, jwtMiddleware)
+	e.GET("/api/order/:id/refunds", refundHandler.ReadByOrderID, jwtMiddleware)
+	e.GET("/api/admin/refunds", refundHandler.ReadAll, jwtMiddleware) // ?status
+	e.PUT("/api/admin/refunds/:id/approve", refundHandler.Approve, jwtMiddleware)
+	e.PUT("/api/admin/refunds/:id/reject", refundHandler.Reject, jwtMiddleware)
 
 	commentHandler := comment.NewHandler(comment.NewService(comment.NewRepository(db)))
 	e.POST("/api/comment", commentHandler.WriteComment, jwtMiddleware) //?productID
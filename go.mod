@@ -1,6 +1,6 @@
 module github.com/Mickey327/rcsp-backend
 
-go 1.20
+go 1.21
 
 require (
 	github.com/georgysavva/scany/v2 v2.0.0
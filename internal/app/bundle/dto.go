@@ -0,0 +1,60 @@
+package bundle
+
+import "github.com/Mickey327/rcsp-backend/internal/app/currency"
+
+type DTO struct {
+	ID           uint64     `json:"id,omitempty"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Price        uint64     `json:"price"`
+	RegularPrice uint64     `json:"regular_price,omitempty"`
+	Savings      uint64     `json:"savings,omitempty"`
+	Available    uint64     `json:"available"`
+	Items        []*ItemDTO `json:"items"`
+}
+
+type ItemDTO struct {
+	ProductID uint64 `json:"product_id"`
+	Name      string `json:"name,omitempty"`
+	Image     string `json:"image,omitempty"`
+	Price     uint64 `json:"price,omitempty"`
+	Quantity  uint64 `json:"quantity"`
+}
+
+func (d *DTO) ToBundle() *Bundle {
+	bundle := &Bundle{
+		ID:          d.ID,
+		Name:        d.Name,
+		Description: d.Description,
+		Price:       d.Price,
+	}
+	for _, item := range d.Items {
+		bundle.Items = append(bundle.Items, &Item{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return bundle
+}
+
+// IsValid reports whether the bundle has a name, a price and at least two different products
+func (d *DTO) IsValid() bool {
+	if d.Name == "" || d.Price == 0 || len(d.Items) < 2 {
+		return false
+	}
+	seen := make(map[uint64]bool, len(d.Items))
+	for _, item := range d.Items {
+		if item.ProductID == 0 || item.Quantity == 0 || seen[item.ProductID] {
+			return false
+		}
+		seen[item.ProductID] = true
+	}
+	return true
+}
+
+// Convert shows prices of the bundle in the quoted currency
+func (d *DTO) Convert(q *currency.Quote) {
+	d.Price = q.Convert(d.Price)
+	d.RegularPrice = q.Convert(d.RegularPrice)
+	d.Savings = q.Convert(d.Savings)
+	for _, item := range d.Items {
+		item.Price = q.Convert(item.Price)
+	}
+}
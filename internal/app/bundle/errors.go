@@ -0,0 +1,9 @@
+package bundle
+
+import "errors"
+
+var (
+	BundleNotFoundErr        = errors.New("набор не найден")
+	BundleAlreadyExistsErr   = errors.New("набор с таким названием уже существует")
+	BundleProductNotFoundErr = errors.New("товар набора не найден")
+)
@@ -0,0 +1,119 @@
+package bundle
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Create(c echo.Context, bundleDTO *DTO) (uint64, error)
+	Read(c echo.Context, id uint64) (*DTO, error)
+	ReadAll(c echo.Context) ([]*DTO, error)
+	Delete(c echo.Context, id uint64) (bool, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	bundleDTO := DTO{}
+
+	if err = c.Bind(&bundleDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	if !bundleDTO.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+
+	id, err := h.service.Create(c, &bundleDTO)
+	if err != nil {
+		if errors.Is(err, BundleAlreadyExistsErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		} else if errors.Is(err, BundleProductNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка создания набора")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"id":      id,
+		"message": "набор был успешно создан",
+	})
+}
+
+func (h *Handler) Read(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id набора")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id набора должно быть положительным")
+	}
+
+	bundleDTO, err := h.service.Read(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, BundleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"bundle": bundleDTO,
+	})
+}
+
+func (h *Handler) ReadAll(c echo.Context) error {
+	bundleDTOs, err := h.service.ReadAll(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, BundleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"bundles": bundleDTOs,
+	})
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id набора")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id набора должно быть положительным")
+	}
+
+	isDeleted, err := h.service.Delete(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isDeleted {
+		return echo.NewHTTPError(http.StatusNotFound, BundleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "набор был успешно удален",
+	})
+}
@@ -0,0 +1,85 @@
+package bundle
+
+import "time"
+
+// Bundle is a set of products sold together for its own price, usually lower than the prices of the products
+type Bundle struct {
+	ID          uint64     `db:"id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	Price       uint64     `db:"price"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	Items       []*Item    `db:"-"`
+}
+
+// Item is the product of the bundle with its current sale price and the stock not reserved by carts
+type Item struct {
+	BundleID  uint64 `db:"bundle_id"`
+	ProductID uint64 `db:"product_id"`
+	Name      string `db:"name"`
+	Image     string `db:"image"`
+	Price     uint64 `db:"price"`
+	Quantity  uint64 `db:"quantity"`
+	Available uint64 `db:"available"`
+}
+
+// RegularPrice returns the price of the bundle products bought separately
+func (b *Bundle) RegularPrice() uint64 {
+	var price uint64
+	for _, item := range b.Items {
+		price += item.Quantity * item.Price
+	}
+	return price
+}
+
+// Available returns how many bundles can be bought from the stock of their products
+func (b *Bundle) Available() uint64 {
+	var available uint64
+	for i, item := range b.Items {
+		sets := item.Available / item.Quantity
+		if i == 0 || sets < available {
+			available = sets
+		}
+	}
+	return available
+}
+
+func (b *Bundle) ToDTO() *DTO {
+	bundleDTO := &DTO{
+		ID:           b.ID,
+		Name:         b.Name,
+		Description:  b.Description,
+		Price:        b.Price,
+		RegularPrice: b.RegularPrice(),
+		Available:    b.Available(),
+	}
+	if bundleDTO.RegularPrice > bundleDTO.Price {
+		bundleDTO.Savings = bundleDTO.RegularPrice - bundleDTO.Price
+	}
+	for _, item := range b.Items {
+		bundleDTO.Items = append(bundleDTO.Items, item.ToDTO())
+	}
+	return bundleDTO
+}
+
+func (i *Item) ToDTO() *ItemDTO {
+	return &ItemDTO{
+		ProductID: i.ProductID,
+		Name:      i.Name,
+		Image:     i.Image,
+		Price:     i.Price,
+		Quantity:  i.Quantity,
+	}
+}
+
+func ToDTOs(bundles []*Bundle) []*DTO {
+	var bundleDTOs []*DTO
+
+	for _, bundle := range bundles {
+		bundleDTOs = append(bundleDTOs, bundle.ToDTO())
+	}
+
+	return bundleDTOs
+}
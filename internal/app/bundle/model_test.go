@@ -0,0 +1,89 @@
+package bundle
+
+import "testing"
+
+func TestBundlePricing(t *testing.T) {
+	tests := []struct {
+		name             string
+		bundle           *Bundle
+		wantRegularPrice uint64
+		wantSavings      uint64
+		wantAvailable    uint64
+	}{
+		{
+			name: "cheaper than products",
+			bundle: &Bundle{Price: 5000, Items: []*Item{
+				{Price: 2000, Quantity: 2, Available: 7},
+				{Price: 1500, Quantity: 1, Available: 10},
+			}},
+			wantRegularPrice: 5500,
+			wantSavings:      500,
+			wantAvailable:    3,
+		},
+		{
+			name: "pricier than products",
+			bundle: &Bundle{Price: 4000, Items: []*Item{
+				{Price: 1000, Quantity: 1, Available: 5},
+				{Price: 2000, Quantity: 1, Available: 2},
+			}},
+			wantRegularPrice: 3000,
+			wantAvailable:    2,
+		},
+		{
+			name: "product out of stock",
+			bundle: &Bundle{Price: 1000, Items: []*Item{
+				{Price: 1000, Quantity: 1, Available: 5},
+				{Price: 500, Quantity: 3, Available: 2},
+			}},
+			wantRegularPrice: 2500,
+			wantSavings:      1500,
+		},
+		{
+			name:   "without items",
+			bundle: &Bundle{Price: 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.bundle.ToDTO()
+			if got.RegularPrice != tt.wantRegularPrice {
+				t.Errorf("RegularPrice = %d, want %d", got.RegularPrice, tt.wantRegularPrice)
+			}
+			if got.Savings != tt.wantSavings {
+				t.Errorf("Savings = %d, want %d", got.Savings, tt.wantSavings)
+			}
+			if got.Available != tt.wantAvailable {
+				t.Errorf("Available = %d, want %d", got.Available, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestDTOIsValid(t *testing.T) {
+	items := func(items ...*ItemDTO) []*ItemDTO {
+		return items
+	}
+
+	tests := []struct {
+		name string
+		dto  *DTO
+		want bool
+	}{
+		{name: "valid", dto: &DTO{Name: "Набор", Price: 1000, Items: items(&ItemDTO{ProductID: 1, Quantity: 1}, &ItemDTO{ProductID: 2, Quantity: 2})}, want: true},
+		{name: "without name", dto: &DTO{Price: 1000, Items: items(&ItemDTO{ProductID: 1, Quantity: 1}, &ItemDTO{ProductID: 2, Quantity: 1})}},
+		{name: "without price", dto: &DTO{Name: "Набор", Items: items(&ItemDTO{ProductID: 1, Quantity: 1}, &ItemDTO{ProductID: 2, Quantity: 1})}},
+		{name: "single product", dto: &DTO{Name: "Набор", Price: 1000, Items: items(&ItemDTO{ProductID: 1, Quantity: 2})}},
+		{name: "repeated product", dto: &DTO{Name: "Набор", Price: 1000, Items: items(&ItemDTO{ProductID: 1, Quantity: 1}, &ItemDTO{ProductID: 1, Quantity: 1})}},
+		{name: "zero quantity", dto: &DTO{Name: "Набор", Price: 1000, Items: items(&ItemDTO{ProductID: 1, Quantity: 1}, &ItemDTO{ProductID: 2})}},
+		{name: "without product", dto: &DTO{Name: "Набор", Price: 1000, Items: items(&ItemDTO{ProductID: 1, Quantity: 1}, &ItemDTO{Quantity: 1})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dto.IsValid(); got != tt.want {
+				t.Errorf("IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package bundle
+
+import (
+	"context"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+// visibleBundles filters out archived bundles and bundles with products hidden from the catalog
+const visibleBundles = `bundles.deleted_at IS NULL
+	AND NOT EXISTS (
+		SELECT 1
+		FROM bundle_items
+			JOIN products ON products.id = bundle_items.product_id
+			JOIN categories ON categories.id = products.category_id
+			JOIN companies ON companies.id = products.company_id
+		WHERE bundle_items.bundle_id = bundles.id
+			AND (products.deleted_at IS NOT NULL OR categories.deleted_at IS NOT NULL OR companies.deleted_at IS NOT NULL)
+	)`
+
+const bundleColumns = "id, name, description, price, created_at, updated_at, deleted_at"
+
+type BundleRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *BundleRepository {
+	return &BundleRepository{db: db}
+}
+
+// Create creates the bundle with its products, products must be visible in the catalog
+func (r *BundleRepository) Create(ctx context.Context, bundle *Bundle) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, "INSERT INTO bundles(name, description, price) VALUES ($1, $2, $3) RETURNING id",
+		bundle.Name, bundle.Description, bundle.Price).Scan(&id)
+	if repository.IsUniqueViolation(err) {
+		return 0, BundleAlreadyExistsErr
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "error creating bundle: %v", bundle)
+	}
+
+	for _, item := range bundle.Items {
+		result, err := r.db.Exec(ctx, `
+			INSERT INTO bundle_items(bundle_id, product_id, quantity)
+			SELECT $1, products.id, $2
+			FROM products
+				JOIN categories ON categories.id = products.category_id
+				JOIN companies ON companies.id = products.company_id
+			WHERE products.id = $3
+				AND products.deleted_at IS NULL AND categories.deleted_at IS NULL AND companies.deleted_at IS NULL`,
+			id, item.Quantity, item.ProductID)
+		if err != nil {
+			return 0, errors.Wrapf(err, "error adding product %d to bundle with id: %d", item.ProductID, id)
+		}
+		if result.RowsAffected() == 0 {
+			return 0, BundleProductNotFoundErr
+		}
+	}
+
+	return id, nil
+}
+
+// Read returns the bundle visible in the catalog with its products
+func (r *BundleRepository) Read(ctx context.Context, id uint64) (*Bundle, error) {
+	var b Bundle
+	err := r.db.Get(ctx, &b, "SELECT "+bundleColumns+" FROM bundles WHERE id = $1 AND "+visibleBundles, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, BundleNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting bundle with id: %d", id)
+	}
+
+	b.Items, err = r.ReadItems(ctx, b.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func (r *BundleRepository) ReadAll(ctx context.Context) ([]*Bundle, error) {
+	bundles := make([]*Bundle, 0)
+	err := r.db.Select(ctx, &bundles, "SELECT "+bundleColumns+" FROM bundles WHERE "+visibleBundles+" ORDER BY created_at DESC")
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting bundles")
+	}
+
+	for _, b := range bundles {
+		b.Items, err = r.ReadItems(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bundles, nil
+}
+
+// ReadItems returns products of the bundle, archived bundles included
+func (r *BundleRepository) ReadItems(ctx context.Context, bundleID uint64) ([]*Item, error) {
+	items := make([]*Item, 0)
+	err := r.db.Select(ctx, &items, `
+		SELECT bundle_items.bundle_id, bundle_items.product_id, bundle_items.quantity, products.name, products.image,
+		       effective_price(products.id, NOW()) as price, available_stock(products.id, NULL) as available
+		FROM bundle_items
+			JOIN products ON products.id = bundle_items.product_id
+		WHERE bundle_items.bundle_id = $1
+		ORDER BY bundle_items.product_id`, bundleID)
+	return items, errors.Wrapf(err, "error getting items of bundle with id: %d", bundleID)
+}
+
+// Delete archives the bundle, orders keep their bundles while carts lose the bundle savings
+func (r *BundleRepository) Delete(ctx context.Context, id uint64) (bool, error) {
+	now := time.Now().UTC()
+	result, err := r.db.Exec(ctx, "UPDATE bundles SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL", now, id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting bundle with id: %d", id)
+}
+
+// RecalculateCarts updates totals of carts holding the bundle, as archived bundles don't discount carts.
+// Orders which fixed the bundle price at checkout keep their totals
+func (r *BundleRepository) RecalculateCarts(ctx context.Context, id uint64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE orders
+		SET total = order_total(orders.id),
+			discount = LEAST(discount, order_total(orders.id)),
+			updated_at = NOW()
+		WHERE orders.id IN (SELECT order_id FROM order_bundles WHERE bundle_id = $1 AND price IS NULL)`, id)
+	return errors.Wrapf(err, "error recalculating carts with bundle with id: %d", id)
+}
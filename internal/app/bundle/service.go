@@ -0,0 +1,107 @@
+package bundle
+
+import (
+	"context"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context, bundle *Bundle) (uint64, error)
+	Read(ctx context.Context, id uint64) (*Bundle, error)
+	ReadAll(ctx context.Context) ([]*Bundle, error)
+	Delete(ctx context.Context, id uint64) (bool, error)
+	RecalculateCarts(ctx context.Context, id uint64) error
+}
+
+// Currencies quotes the currency the client asked prices to be shown in
+type Currencies interface {
+	DisplayQuote(ctx context.Context) *currency.Quote
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type BundleService struct {
+	repository Repository
+	currencies Currencies
+	transactor Transactor
+}
+
+func NewService(repository Repository, currencies Currencies, transactor Transactor) *BundleService {
+	return &BundleService{
+		repository: repository,
+		currencies: currencies,
+		transactor: transactor,
+	}
+}
+
+// Create creates the bundle with all of its products or nothing at all
+func (s *BundleService) Create(c echo.Context, bundleDTO *DTO) (uint64, error) {
+	var id uint64
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		id, err = s.repository.Create(ctx, bundleDTO.ToBundle())
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *BundleService) Read(c echo.Context, id uint64) (*DTO, error) {
+	bundle, err := s.repository.Read(c.Request().Context(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.display(c, bundle.ToDTO())[0], nil
+}
+
+func (s *BundleService) ReadAll(c echo.Context) ([]*DTO, error) {
+	bundles, err := s.repository.ReadAll(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bundles) == 0 {
+		return nil, BundleNotFoundErr
+	}
+
+	return s.display(c, ToDTOs(bundles)...), nil
+}
+
+// Delete archives the bundle and recalculates the carts holding it together, so carts never show the lost discount
+func (s *BundleService) Delete(c echo.Context, id uint64) (bool, error) {
+	var isDeleted bool
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		isDeleted, err = s.repository.Delete(ctx, id)
+		if err != nil || !isDeleted {
+			return err
+		}
+		return s.repository.RecalculateCarts(ctx, id)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return isDeleted, nil
+}
+
+// display converts prices of the bundles to the currency requested by the client
+func (s *BundleService) display(c echo.Context, bundleDTOs ...*DTO) []*DTO {
+	q := s.currencies.DisplayQuote(c.Request().Context())
+	for _, bundleDTO := range bundleDTOs {
+		bundleDTO.Convert(q)
+	}
+	return bundleDTOs
+}
@@ -0,0 +1,144 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/bundle"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/labstack/echo/v4"
+)
+
+// AddBundle adds products of the bundle to the user's cart and links them to the bundle, so the cart total
+// gets the bundle price. Every product must have enough stock not reserved by other carts for all the bundles
+func (s *CartService) AddBundle(c echo.Context, userID, bundleID, quantity uint64) (*order.DTO, error) {
+	if quantity == 0 {
+		return nil, NotPositiveQuantityErr
+	}
+
+	var cart *order.Order
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		cart, err = s.orderRepository.ReadCurrentUserArrangingOrderEager(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		b, err := s.bundleRepository.Read(ctx, bundleID)
+		if err != nil {
+			return err
+		}
+
+		inCart := make(map[uint64]uint64, len(cart.OrderItems))
+		for _, item := range cart.OrderItems {
+			inCart[item.Product.ID] = uint64(item.Quantity)
+		}
+
+		for _, item := range b.Items {
+			available, err := s.reservations.Available(ctx, item.ProductID, cart.ID)
+			if err != nil {
+				return err
+			}
+			if inCart[item.ProductID]+quantity*item.Quantity > available {
+				return BundleExceedsStockErr
+			}
+		}
+
+		for _, item := range b.Items {
+			if err = s.addBundleItem(ctx, cart.ID, item, quantity, inCart); err != nil {
+				return err
+			}
+		}
+
+		if err = s.orderRepository.AddBundle(ctx, cart.ID, bundleID, quantity); err != nil {
+			return err
+		}
+
+		cart, err = s.readRefreshedCart(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+func (s *CartService) addBundleItem(ctx context.Context, cartID uint64, item *bundle.Item, quantity uint64, inCart map[uint64]uint64) error {
+	dto := &orderItem.DTO{
+		OrderID:  cartID,
+		Product:  &product.DTO{ID: item.ProductID},
+		Quantity: int(quantity * item.Quantity),
+	}
+
+	if _, ok := inCart[item.ProductID]; ok {
+		_, err := s.orderItemRepository.Update(ctx, dto.ToOrderItem())
+		return err
+	}
+
+	isCreated, err := s.orderItemRepository.Create(ctx, dto.ToOrderItem())
+	if err == nil && !isCreated {
+		err = product.ProductNotFoundErr
+	}
+	return err
+}
+
+// RemoveBundle removes the bundle from the user's cart together with the quantities of its products added with it
+func (s *CartService) RemoveBundle(c echo.Context, userID, bundleID uint64) (*order.DTO, error) {
+	var cart *order.Order
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		cart, err = s.orderRepository.ReadCurrentUserArrangingOrderLazy(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		quantity, err := s.orderRepository.RemoveBundle(ctx, cart.ID, bundleID)
+		if err != nil {
+			return err
+		}
+
+		items, err := s.bundleRepository.ReadItems(ctx, bundleID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err = s.removeBundleItem(ctx, cart.ID, item, quantity); err != nil {
+				return err
+			}
+		}
+
+		cart, err = s.readRefreshedCart(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+// removeBundleItem takes the bundle quantity of the product out of the cart, the item is removed
+// if the customer has lowered its quantity since the bundle was added
+func (s *CartService) removeBundleItem(ctx context.Context, cartID uint64, item *bundle.Item, quantity uint64) error {
+	existing, err := s.orderItemRepository.ReadByOrderAndProductID(ctx, cartID, item.ProductID)
+	if errors.Is(err, orderItem.OrderItemNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	taken := quantity * item.Quantity
+	if uint64(existing.Quantity) <= taken {
+		_, err = s.orderItemRepository.Delete(ctx, existing)
+		return err
+	}
+
+	existing.Quantity = -int(taken)
+	_, err = s.orderItemRepository.Update(ctx, existing)
+	return err
+}
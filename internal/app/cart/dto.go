@@ -0,0 +1,15 @@
+package cart
+
+type NamedCartDTO struct {
+	Name string `json:"name"`
+}
+
+// ReorderItemDTO reports what happened to the item of the past order, the reason is given for skipped items
+type ReorderItemDTO struct {
+	ProductID uint64 `json:"product_id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Requested uint64 `json:"requested"`
+	Added     uint64 `json:"added"`
+	Reason    string `json:"reason,omitempty"`
+}
@@ -3,6 +3,10 @@ package cart
 import "errors"
 
 var (
-	WrongCartErr           = errors.New("пользователь не может изменять чужую корзину")
-	NotPositiveQuantityErr = errors.New("пользователь не может сделать количество позиции менее 1")
+	WrongCartErr            = errors.New("пользователь не может изменять чужую корзину")
+	NotPositiveQuantityErr  = errors.New("пользователь не может сделать количество позиции менее 1")
+	QuantityExceedsStockErr = errors.New("количество товара в корзине превышает остаток на складе")
+	ReorderNotArrangedErr   = errors.New("повторить можно только оформленный заказ")
+	CartNameErr             = errors.New("название корзины должно быть непустым и не длиннее 100 символов")
+	BundleExceedsStockErr   = errors.New("товаров набора недостаточно на складе")
 )
@@ -6,15 +6,28 @@ import (
 	"strconv"
 
 	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/bundle"
 	"github.com/Mickey327/rcsp-backend/internal/app/order"
 	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
 	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/Mickey327/rcsp-backend/internal/app/promo"
 	"github.com/labstack/echo/v4"
 )
 
 type Service interface {
-	UpdateCart(c echo.Context, dto *orderItem.DTO) (*order.DTO, error)
-	RemoveFromCart(c echo.Context, dto *orderItem.DTO) (*order.DTO, error)
+	UpdateCart(c echo.Context, dto *orderItem.DTO, userID uint64) (*order.DTO, error)
+	RemoveFromCart(c echo.Context, dto *orderItem.DTO, userID uint64) (*order.DTO, error)
+	ApplyPromoCode(c echo.Context, userID uint64, code string) (*order.DTO, error)
+	RemovePromoCode(c echo.Context, userID uint64) (*order.DTO, error)
+	ReadNamedCarts(c echo.Context, userID uint64) ([]*order.DTO, error)
+	CreateNamedCart(c echo.Context, userID uint64, dto *NamedCartDTO) (*order.DTO, error)
+	DeleteNamedCart(c echo.Context, userID, id uint64) error
+	SaveForLater(c echo.Context, userID, productID uint64) (*order.DTO, error)
+	MoveToNamedCart(c echo.Context, userID, cartID, productID uint64) (*order.DTO, error)
+	MoveToCart(c echo.Context, userID, cartID, productID uint64) (*order.DTO, *order.CartCheckDTO, error)
+	Reorder(c echo.Context, userID, orderID uint64) (*order.DTO, []*ReorderItemDTO, error)
+	AddBundle(c echo.Context, userID, bundleID, quantity uint64) (*order.DTO, error)
+	RemoveBundle(c echo.Context, userID, bundleID uint64) (*order.DTO, error)
 }
 
 type Handler struct {
@@ -28,7 +41,7 @@ func NewHandler(service Service) *Handler {
 }
 
 func (h *Handler) UpdateCart(c echo.Context) error {
-	_, err := auth.GetUserDataAndCheckRole(c, "user")
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
 
 	if err != nil {
 		return err
@@ -71,12 +84,16 @@ func (h *Handler) UpdateCart(c echo.Context) error {
 		ID: productID,
 	}
 
-	o, err := h.service.UpdateCart(c, dto)
+	o, err := h.service.UpdateCart(c, dto, userData.ID)
 
 	if err != nil {
 		if errors.Is(err, WrongCartErr) || errors.Is(err, NotPositiveQuantityErr) {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-		} else if errors.Is(err, order.OrderNotFoundErr) {
+		} else if errors.Is(err, QuantityExceedsStockErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		} else if errors.Is(err, order.ForeignOrderErr) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		} else if errors.Is(err, order.OrderNotFoundErr) || errors.Is(err, product.ProductNotFoundErr) {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		} else {
 			return echo.NewHTTPError(http.StatusInternalServerError, "ошибка произошла во время обновления корзины")
@@ -90,7 +107,7 @@ func (h *Handler) UpdateCart(c echo.Context) error {
 }
 
 func (h *Handler) RemoveFromCart(c echo.Context) error {
-	_, err := auth.GetUserDataAndCheckRole(c, "user")
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
 
 	if err != nil {
 		return err
@@ -129,11 +146,14 @@ func (h *Handler) RemoveFromCart(c echo.Context) error {
 		ID: productID,
 	}
 
-	o, err := h.service.RemoveFromCart(c, dto)
+	o, err := h.service.RemoveFromCart(c, dto, userData.ID)
 	if err != nil {
 		if errors.Is(err, orderItem.OrderItemNotFound) || errors.Is(err, order.OrderNotFoundErr) {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
+		if errors.Is(err, order.ForeignOrderErr) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
 		if errors.Is(err, WrongCartErr) {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		} else {
@@ -146,3 +166,327 @@ func (h *Handler) RemoveFromCart(c echo.Context) error {
 		"order": o,
 	})
 }
+
+func (h *Handler) ApplyPromoCode(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+
+	if err != nil {
+		return err
+	}
+
+	applyDTO := promo.ApplyDTO{}
+
+	if err = c.Bind(&applyDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+	if applyDTO.Code == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+
+	o, err := h.service.ApplyPromoCode(c, userData.ID, applyDTO.Code)
+	if err != nil {
+		if errors.Is(err, promo.PromoCodeNotFoundErr) || errors.Is(err, order.OrderNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, promo.PromoCodeNotStartedErr) || errors.Is(err, promo.PromoCodeExpiredErr) ||
+			errors.Is(err, promo.PromoCodeExhaustedErr) || errors.Is(err, promo.PromoCodeUserLimitErr) ||
+			errors.Is(err, promo.PromoCodeMinTotalErr) || errors.Is(err, promo.PromoCodeNotApplicableErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка применения промокода")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+	})
+}
+
+func (h *Handler) RemovePromoCode(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+
+	if err != nil {
+		return err
+	}
+
+	o, err := h.service.RemovePromoCode(c, userData.ID)
+	if err != nil {
+		if errors.Is(err, order.OrderNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка удаления промокода")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+	})
+}
+
+func (h *Handler) ReadNamedCarts(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	carts, err := h.service.ReadNamedCarts(c, userData.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения корзин")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"carts": carts,
+	})
+}
+
+func (h *Handler) CreateNamedCart(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	dto := NamedCartDTO{}
+
+	if err = c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	cart, err := h.service.CreateNamedCart(c, userData.ID, &dto)
+	if err != nil {
+		if errors.Is(err, CartNameErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, order.CartNameTakenErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка создания корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"cart": cart,
+	})
+}
+
+func (h *Handler) DeleteNamedCart(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "неверный формат id корзины")
+	}
+
+	if err = h.service.DeleteNamedCart(c, userData.ID, id); err != nil {
+		if errors.Is(err, order.OrderNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка удаления корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "корзина была успешно удалена",
+	})
+}
+
+func (h *Handler) SaveForLater(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	productID, err := parseProductID(c)
+	if err != nil {
+		return err
+	}
+
+	o, err := h.service.SaveForLater(c, userData.ID, productID)
+	if err != nil {
+		return moveItemError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+	})
+}
+
+func (h *Handler) MoveToNamedCart(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	cartID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "неверный формат id корзины")
+	}
+
+	productID, err := parseProductID(c)
+	if err != nil {
+		return err
+	}
+
+	o, err := h.service.MoveToNamedCart(c, userData.ID, cartID, productID)
+	if err != nil {
+		return moveItemError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+	})
+}
+
+// MoveToCart returns the product to the cart along with the changes found when the cart was re-checked
+func (h *Handler) MoveToCart(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	cartID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "неверный формат id корзины")
+	}
+
+	productID, err := parseProductID(c)
+	if err != nil {
+		return err
+	}
+
+	o, check, err := h.service.MoveToCart(c, userData.ID, cartID, productID)
+	if err != nil {
+		return moveItemError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+		"check": check,
+	})
+}
+
+// Reorder copies items of the past order to the cart and reports which of them were added, adjusted or skipped
+func (h *Handler) Reorder(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "неверный формат id заказа")
+	}
+
+	o, report, err := h.service.Reorder(c, userData.ID, orderID)
+	if err != nil {
+		if errors.Is(err, order.OrderNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, order.ForeignOrderErr) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, ReorderNotArrangedErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка повторения заказа")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+		"items": report,
+	})
+}
+
+// AddBundle adds the bundle with its products to the cart, one bundle unless the quantity is given
+func (h *Handler) AddBundle(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	bundleID, err := parseBundleID(c)
+	if err != nil {
+		return err
+	}
+
+	quantity := uint64(1)
+	if quantityString := c.QueryParam("quantity"); quantityString != "" {
+		quantity, err = strconv.ParseUint(quantityString, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга количества наборов")
+		}
+	}
+
+	o, err := h.service.AddBundle(c, userData.ID, bundleID, quantity)
+	if err != nil {
+		if errors.Is(err, NotPositiveQuantityErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		} else if errors.Is(err, BundleExceedsStockErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		} else if errors.Is(err, bundle.BundleNotFoundErr) || errors.Is(err, order.OrderNotFoundErr) ||
+			errors.Is(err, product.ProductNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка добавления набора в корзину")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+	})
+}
+
+func (h *Handler) RemoveBundle(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	bundleID, err := parseBundleID(c)
+	if err != nil {
+		return err
+	}
+
+	o, err := h.service.RemoveBundle(c, userData.ID, bundleID)
+	if err != nil {
+		if errors.Is(err, order.BundleNotInCartErr) || errors.Is(err, order.OrderNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка удаления набора из корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": o,
+	})
+}
+
+func moveItemError(err error) error {
+	if errors.Is(err, orderItem.OrderItemNotFound) || errors.Is(err, order.OrderNotFoundErr) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, "ошибка переноса товара")
+}
+
+func parseProductID(c echo.Context) (uint64, error) {
+	productID, err := strconv.ParseUint(c.QueryParam("productID"), 10, 64)
+	if err != nil || productID == 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id товара")
+	}
+	return productID, nil
+}
+
+func parseBundleID(c echo.Context) (uint64, error) {
+	bundleID, err := strconv.ParseUint(c.QueryParam("bundleID"), 10, 64)
+	if err != nil || bundleID == 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id набора")
+	}
+	return bundleID, nil
+}
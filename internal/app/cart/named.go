@@ -0,0 +1,155 @@
+package cart
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/labstack/echo/v4"
+)
+
+// SavedForLaterName is the name of the cart items saved for later are moved to
+const SavedForLaterName = "Отложенные товары"
+
+// maxCartNameLength limits names of the carts in characters
+const maxCartNameLength = 100
+
+// ReadNamedCarts returns named carts of the user, the saved for later items among them
+func (s *CartService) ReadNamedCarts(c echo.Context, userID uint64) ([]*order.DTO, error) {
+	carts, err := s.orderRepository.ReadNamedCarts(c.Request().Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cartDTOs := make([]*order.DTO, 0, len(carts))
+	for _, cart := range carts {
+		cartDTOs = append(cartDTOs, s.toDTO(c, cart))
+	}
+
+	return cartDTOs, nil
+}
+
+func (s *CartService) CreateNamedCart(c echo.Context, userID uint64, dto *NamedCartDTO) (*order.DTO, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" || len([]rune(name)) > maxCartNameLength {
+		return nil, CartNameErr
+	}
+
+	cart, err := s.orderRepository.CreateNamedCart(c.Request().Context(), userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+func (s *CartService) DeleteNamedCart(c echo.Context, userID, id uint64) error {
+	isDeleted, err := s.orderRepository.DeleteNamedCart(c.Request().Context(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !isDeleted {
+		return order.OrderNotFoundErr
+	}
+
+	return nil
+}
+
+// SaveForLater moves the product from the cart to the saved for later items
+func (s *CartService) SaveForLater(c echo.Context, userID, productID uint64) (*order.DTO, error) {
+	var cart *order.Order
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		saved, err := s.orderRepository.ReadOrCreateNamedCart(ctx, userID, SavedForLaterName)
+		if err != nil {
+			return err
+		}
+
+		cart, err = s.moveFromCart(ctx, userID, saved.ID, productID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+// MoveToNamedCart moves the product from the cart to the named cart of the user
+func (s *CartService) MoveToNamedCart(c echo.Context, userID, cartID, productID uint64) (*order.DTO, error) {
+	var cart *order.Order
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		if _, err := s.orderRepository.ReadNamedCart(ctx, cartID, userID); err != nil {
+			return err
+		}
+
+		var err error
+		cart, err = s.moveFromCart(ctx, userID, cartID, productID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+// MoveToCart moves the product from the named cart back to the cart. The cart is re-checked afterwards,
+// so the product gets its current price and its quantity is reduced to the stock
+func (s *CartService) MoveToCart(c echo.Context, userID, cartID, productID uint64) (*order.DTO, *order.CartCheckDTO, error) {
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		if _, err := s.orderRepository.ReadNamedCart(ctx, cartID, userID); err != nil {
+			return err
+		}
+
+		cart, err := s.orderRepository.ReadCurrentUserArrangingOrderLazy(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		isMoved, err := s.orderRepository.MoveItem(ctx, cartID, cart.ID, productID)
+		if err != nil {
+			return err
+		}
+		if !isMoved {
+			return orderItem.OrderItemNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	check, err := s.validator.ValidateCart(c, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cart, err := s.readRefreshedCart(c.Request().Context(), userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.toDTO(c, cart), check, nil
+}
+
+// moveFromCart moves the product from the user's cart to the named cart and returns the changed cart
+func (s *CartService) moveFromCart(ctx context.Context, userID, cartID, productID uint64) (*order.Order, error) {
+	cart, err := s.orderRepository.ReadCurrentUserArrangingOrderLazy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	isMoved, err := s.orderRepository.MoveItem(ctx, cart.ID, cartID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMoved {
+		return nil, orderItem.OrderItemNotFound
+	}
+
+	return s.readRefreshedCart(ctx, userID)
+}
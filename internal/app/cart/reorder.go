@@ -0,0 +1,116 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/labstack/echo/v4"
+)
+
+// Statuses of the items of the past order copied to the cart
+const (
+	ReorderAdded    = "Добавлен"
+	ReorderAdjusted = "Количество уменьшено"
+	ReorderSkipped  = "Пропущен"
+)
+
+// Reorder copies items of the user's arranged order to the cart. Quantities are limited to the stock
+// not reserved by other carts and not taken by the cart yet, removed products and products out of stock are skipped
+func (s *CartService) Reorder(c echo.Context, userID, orderID uint64) (*order.DTO, []*ReorderItemDTO, error) {
+	var cart *order.Order
+	var report []*ReorderItemDTO
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		past, err := s.orderRepository.ReadByIdEager(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if past.UserID != userID {
+			return order.ForeignOrderErr
+		}
+		if !past.IsArranged {
+			return ReorderNotArrangedErr
+		}
+
+		cart, err = s.orderRepository.ReadCurrentUserArrangingOrderEager(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		inCart := make(map[uint64]uint64, len(cart.OrderItems))
+		for _, item := range cart.OrderItems {
+			inCart[item.Product.ID] = uint64(item.Quantity)
+		}
+
+		report = make([]*ReorderItemDTO, 0, len(past.OrderItems))
+		for _, item := range past.OrderItems {
+			reported, err := s.reorderItem(ctx, cart.ID, item, inCart)
+			if err != nil {
+				return err
+			}
+			report = append(report, reported)
+		}
+
+		cart, err = s.readRefreshedCart(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.toDTO(c, cart), report, nil
+}
+
+func (s *CartService) reorderItem(ctx context.Context, cartID uint64, item *orderItem.OrderItem, inCart map[uint64]uint64) (*ReorderItemDTO, error) {
+	reported := &ReorderItemDTO{
+		ProductID: item.Product.ID,
+		Name:      item.ProductName,
+		Requested: uint64(item.Quantity),
+	}
+
+	if _, err := s.productRepository.Read(ctx, item.Product.ID); err != nil {
+		if !errors.Is(err, product.ProductNotFoundErr) {
+			return nil, err
+		}
+		reported.Status = ReorderSkipped
+		reported.Reason = order.ItemRemoved
+		return reported, nil
+	}
+
+	available, err := s.reservations.Available(ctx, item.Product.ID, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if available <= inCart[item.Product.ID] {
+		reported.Status = ReorderSkipped
+		reported.Reason = order.ItemOutOfStock
+		return reported, nil
+	}
+
+	reported.Added = reported.Requested
+	reported.Status = ReorderAdded
+	if room := available - inCart[item.Product.ID]; room < reported.Requested {
+		reported.Added = room
+		reported.Status = ReorderAdjusted
+	}
+
+	dto := &orderItem.DTO{
+		OrderID:  cartID,
+		Product:  &product.DTO{ID: item.Product.ID},
+		Quantity: int(reported.Added),
+	}
+	if _, ok := inCart[item.Product.ID]; ok {
+		_, err = s.orderItemRepository.Update(ctx, dto.ToOrderItem())
+	} else {
+		_, err = s.orderItemRepository.Create(ctx, dto.ToOrderItem())
+	}
+	if err != nil {
+		return nil, err
+	}
+	inCart[item.Product.ID] += reported.Added
+
+	return reported, nil
+}
@@ -2,16 +2,30 @@ package cart
 
 import (
 	"context"
+	"errors"
 
+	"github.com/Mickey327/rcsp-backend/internal/app/bundle"
 	"github.com/Mickey327/rcsp-backend/internal/app/order"
 	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
 	"github.com/labstack/echo/v4"
 )
 
 type OrderRepository interface {
-	GetUserIDByNotArrangedOrderID(ctx context.Context, orderID uint64) (uint64, error)
+	LockById(ctx context.Context, id uint64) (*order.Order, error)
 	ReadCurrentUserArrangingOrderLazy(ctx context.Context, userID uint64) (*order.Order, error)
 	ReadCurrentUserArrangingOrderEager(ctx context.Context, userID uint64) (*order.Order, error)
+	ReadByIdEager(ctx context.Context, id uint64) (*order.Order, error)
+	UpdateDiscount(ctx context.Context, orderID uint64, promoCodeID *uint64, discount uint64) error
+	CreateNamedCart(ctx context.Context, userID uint64, name string) (*order.Order, error)
+	ReadOrCreateNamedCart(ctx context.Context, userID uint64, name string) (*order.Order, error)
+	ReadNamedCarts(ctx context.Context, userID uint64) ([]*order.Order, error)
+	ReadNamedCart(ctx context.Context, id, userID uint64) (*order.Order, error)
+	DeleteNamedCart(ctx context.Context, id, userID uint64) (bool, error)
+	MoveItem(ctx context.Context, fromID, toID, productID uint64) (bool, error)
+	AddBundle(ctx context.Context, orderID, bundleID, quantity uint64) error
+	RemoveBundle(ctx context.Context, orderID, bundleID uint64) (uint64, error)
+	RemoveBundlesOfProduct(ctx context.Context, orderID, productID uint64) error
 }
 
 type OrderItemRepository interface {
@@ -21,72 +35,249 @@ type OrderItemRepository interface {
 	Delete(ctx context.Context, orderItem *orderItem.OrderItem) (bool, error)
 }
 
+type ProductRepository interface {
+	Read(ctx context.Context, id uint64) (*product.Product, error)
+}
+
+type BundleRepository interface {
+	Read(ctx context.Context, id uint64) (*bundle.Bundle, error)
+	ReadItems(ctx context.Context, bundleID uint64) ([]*bundle.Item, error)
+}
+
+// Promotions discounts carts with promo codes
+type Promotions interface {
+	Apply(ctx context.Context, cart *order.Order, code string) error
+	Refresh(ctx context.Context, cart *order.Order) error
+}
+
+// Reservations hold stock for the items of carts while customers are shopping
+type Reservations interface {
+	Reserve(ctx context.Context, orderID uint64) error
+	Available(ctx context.Context, productID, orderID uint64) (uint64, error)
+}
+
+// Validator re-checks items of the user's cart against their products and corrects the cart
+type Validator interface {
+	ValidateCart(c echo.Context, userID uint64) (*order.CartCheckDTO, error)
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type CartService struct {
 	orderRepository     OrderRepository
 	orderItemRepository OrderItemRepository
+	productRepository   ProductRepository
+	bundleRepository    BundleRepository
+	promotions          Promotions
+	currencies          order.Currencies
+	reservations        Reservations
+	validator           Validator
+	transactor          Transactor
 }
 
-func NewService(orderRepository OrderRepository, orderItemRepository OrderItemRepository) *CartService {
+func NewService(orderRepository OrderRepository, orderItemRepository OrderItemRepository, productRepository ProductRepository,
+	bundleRepository BundleRepository, promotions Promotions, currencies order.Currencies, reservations Reservations,
+	validator Validator, transactor Transactor) *CartService {
 	return &CartService{
 		orderRepository:     orderRepository,
 		orderItemRepository: orderItemRepository,
+		productRepository:   productRepository,
+		bundleRepository:    bundleRepository,
+		promotions:          promotions,
+		currencies:          currencies,
+		reservations:        reservations,
+		validator:           validator,
+		transactor:          transactor,
 	}
 }
 
-func (s *CartService) UpdateCart(c echo.Context, dto *orderItem.DTO) (*order.DTO, error) {
-	userID, err := s.orderRepository.GetUserIDByNotArrangedOrderID(c.Request().Context(), dto.OrderID)
+func (s *CartService) UpdateCart(c echo.Context, dto *orderItem.DTO, userID uint64) (*order.DTO, error) {
+	var o *order.Order
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		if err := s.lockUserCart(ctx, dto.OrderID, userID); err != nil {
+			return err
+		}
+
+		item, err := s.orderItemRepository.ReadByOrderAndProductID(ctx, dto.OrderID, dto.Product.ID)
+		if err != nil && !errors.Is(err, orderItem.OrderItemNotFound) {
+			return err
+		}
+
+		if err = s.checkStock(ctx, item, dto); err != nil {
+			return err
+		}
+
+		if item != nil {
+			if dto.Quantity+item.Quantity <= 0 {
+				return NotPositiveQuantityErr
+			}
+			if _, err = s.orderItemRepository.Update(ctx, dto.ToOrderItem()); err != nil {
+				return err
+			}
+		} else {
+			if dto.Quantity <= 0 {
+				return NotPositiveQuantityErr
+			}
+			isCreated, err := s.orderItemRepository.Create(ctx, dto.ToOrderItem())
+			if err != nil {
+				return err
+			}
+			if !isCreated {
+				return product.ProductNotFoundErr
+			}
+		}
+
+		o, err = s.readRefreshedCart(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, o), nil
+}
+
+// lockUserCart locks the cart the items are changed in, users can change only their own current carts
+func (s *CartService) lockUserCart(ctx context.Context, orderID, userID uint64) error {
+	cart, err := s.orderRepository.LockById(ctx, orderID)
+	if errors.Is(err, order.OrderNotFoundErr) {
+		return WrongCartErr
+	}
 	if err != nil {
-		return nil, WrongCartErr
+		return err
 	}
 
-	item, err := s.orderItemRepository.ReadByOrderAndProductID(c.Request().Context(), dto.OrderID, dto.Product.ID)
+	if cart.UserID != userID {
+		return order.ForeignOrderErr
+	}
+	if cart.IsArranged || cart.CartName != nil {
+		return WrongCartErr
+	}
+	return nil
+}
+
+// checkStock rejects adding more of the product than its stock not reserved by other carts,
+// lowering the quantity is always allowed
+func (s *CartService) checkStock(ctx context.Context, item *orderItem.OrderItem, dto *orderItem.DTO) error {
+	if dto.Quantity <= 0 {
+		return nil
+	}
 
+	if _, err := s.productRepository.Read(ctx, dto.Product.ID); err != nil {
+		return err
+	}
+
+	available, err := s.reservations.Available(ctx, dto.Product.ID, dto.OrderID)
+	if err != nil {
+		return err
+	}
+
+	quantity := uint64(dto.Quantity)
 	if item != nil {
+		quantity += uint64(item.Quantity)
+	}
+	if quantity > available {
+		return QuantityExceedsStockErr
+	}
 
-		if dto.Quantity+item.Quantity > 0 {
-			_, err = s.orderItemRepository.Update(c.Request().Context(), dto.ToOrderItem())
-		} else {
-			err = NotPositiveQuantityErr
+	return nil
+}
+
+func (s *CartService) RemoveFromCart(c echo.Context, dto *orderItem.DTO, userID uint64) (*order.DTO, error) {
+	var o *order.Order
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		if err := s.lockUserCart(ctx, dto.OrderID, userID); err != nil {
+			return err
 		}
-	} else {
-		if dto.Quantity > 0 {
-			_, err = s.orderItemRepository.Create(c.Request().Context(), dto.ToOrderItem())
-		} else {
-			err = NotPositiveQuantityErr
+
+		isDeleted, err := s.orderItemRepository.Delete(ctx, dto.ToOrderItem())
+		if err != nil {
+			return err
+		}
+
+		if !isDeleted {
+			return orderItem.OrderItemNotFound
+		}
+
+		// the bundles lose the product, so they can't discount the cart anymore
+		if err = s.orderRepository.RemoveBundlesOfProduct(ctx, dto.OrderID, dto.Product.ID); err != nil {
+			return err
 		}
+
+		o, err = s.readRefreshedCart(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return s.toDTO(c, o), nil
+}
+
+// ApplyPromoCode discounts the user's cart with the promo code, it replaces the promo code applied before
+func (s *CartService) ApplyPromoCode(c echo.Context, userID uint64, code string) (*order.DTO, error) {
+	cart, err := s.orderRepository.ReadCurrentUserArrangingOrderEager(c.Request().Context(), userID)
 	if err != nil {
 		return nil, err
 	}
 
-	o, err := s.orderRepository.ReadCurrentUserArrangingOrderEager(c.Request().Context(), userID)
+	if err = s.promotions.Apply(c.Request().Context(), cart, code); err != nil {
+		return nil, err
+	}
+
+	cart, err = s.orderRepository.ReadCurrentUserArrangingOrderEager(c.Request().Context(), userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return o.ToDTO(), nil
+	return s.toDTO(c, cart), nil
 }
 
-func (s *CartService) RemoveFromCart(c echo.Context, dto *orderItem.DTO) (*order.DTO, error) {
-	userID, err := s.orderRepository.GetUserIDByNotArrangedOrderID(c.Request().Context(), dto.OrderID)
+func (s *CartService) RemovePromoCode(c echo.Context, userID uint64) (*order.DTO, error) {
+	cart, err := s.orderRepository.ReadCurrentUserArrangingOrderLazy(c.Request().Context(), userID)
 	if err != nil {
-		return nil, WrongCartErr
+		return nil, err
 	}
 
-	isDeleted, err := s.orderItemRepository.Delete(c.Request().Context(), dto.ToOrderItem())
-	if err != nil {
+	if err = s.orderRepository.UpdateDiscount(c.Request().Context(), cart.ID, nil, 0); err != nil {
 		return nil, err
 	}
 
-	if !isDeleted {
-		return nil, orderItem.OrderItemNotFound
+	cart, err = s.orderRepository.ReadCurrentUserArrangingOrderEager(c.Request().Context(), userID)
+	if err != nil {
+		return nil, err
 	}
 
-	o, err := s.orderRepository.ReadCurrentUserArrangingOrderEager(c.Request().Context(), userID)
+	return s.toDTO(c, cart), nil
+}
+
+// toDTO shows the cart in the currency requested by the client
+func (s *CartService) toDTO(c echo.Context, cart *order.Order) *order.DTO {
+	cart.SetQuote(s.currencies.DisplayQuote(c.Request().Context()))
+	return cart.ToDTO()
+}
+
+// readRefreshedCart reads the user's cart after it has changed, reserving its items
+// and recalculating the discount of its promo code
+func (s *CartService) readRefreshedCart(ctx context.Context, userID uint64) (*order.Order, error) {
+	cart, err := s.orderRepository.ReadCurrentUserArrangingOrderEager(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return o.ToDTO(), nil
+	if err = s.reservations.Reserve(ctx, cart.ID); err != nil {
+		return nil, err
+	}
+
+	if cart.PromoCodeID == nil {
+		return cart, nil
+	}
+
+	if err = s.promotions.Refresh(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	return s.orderRepository.ReadCurrentUserArrangingOrderEager(ctx, userID)
 }
@@ -5,4 +5,5 @@ import "errors"
 var (
 	CategoryNotFoundErr      = errors.New("категория не найдена")
 	CategoryAlreadyExistsErr = errors.New("категория с таким именем уже существует")
+	CategoryInUseErr         = errors.New("категория используется товарами и не может быть удалена окончательно")
 )
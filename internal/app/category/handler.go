@@ -1,6 +1,7 @@
 package category
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -14,6 +15,9 @@ type Service interface {
 	ReadAll(c echo.Context) ([]*DTO, error)
 	Update(c echo.Context, categoryDTO *DTO) (bool, error)
 	Delete(c echo.Context, id uint64) (bool, error)
+	ReadDeleted(c echo.Context) ([]*DTO, error)
+	Restore(c echo.Context, id uint64) (bool, error)
+	Purge(c echo.Context, id uint64) (bool, error)
 }
 
 type Handler struct {
@@ -143,3 +147,84 @@ func (h *Handler) Delete(c echo.Context) error {
 		"message": "категория была успешно удалена",
 	})
 }
+
+func (h *Handler) ReadDeleted(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	categoryDTOs, err := h.service.ReadDeleted(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":       http.StatusOK,
+		"categories": categoryDTOs,
+	})
+}
+
+func (h *Handler) Restore(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id категории")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id категории должно быть положительным")
+	}
+
+	isRestored, err := h.service.Restore(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isRestored {
+		return echo.NewHTTPError(http.StatusNotFound, CategoryNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "категория была успешно восстановлена",
+	})
+}
+
+func (h *Handler) Purge(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id категории")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id категории должно быть положительным")
+	}
+
+	isPurged, err := h.service.Purge(c, id)
+	if err != nil {
+		if errors.Is(err, CategoryInUseErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isPurged {
+		return echo.NewHTTPError(http.StatusNotFound, CategoryNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "категория была успешно удалена окончательно",
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -35,28 +36,48 @@ func (r *CategoryRepository) Create(ctx context.Context, category *Category) (ui
 func (r *CategoryRepository) ReadAll(ctx context.Context) ([]*Category, error) {
 	categories := make([]*Category, 0)
 	err := r.db.Select(ctx, &categories,
-		"SELECT id, name, created_at, updated_at FROM categories")
+		"SELECT id, name, created_at, updated_at FROM categories WHERE deleted_at IS NULL")
 	return categories, errors.Wrap(err, "error getting categories")
 }
 
 func (r *CategoryRepository) Read(ctx context.Context, id uint64) (*Category, error) {
 	var c Category
-	err := r.db.Get(ctx, &c, "SELECT id,name,created_at,updated_at FROM categories WHERE id = $1", id)
+	err := r.db.Get(ctx, &c, "SELECT id,name,created_at,updated_at FROM categories WHERE id = $1 AND deleted_at IS NULL", id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, CategoryNotFoundErr
 	}
-	return &c, nil
+	return &c, errors.Wrapf(err, "error getting category with id: %d", id)
 }
 
 func (r *CategoryRepository) Update(ctx context.Context, category *Category) (bool, error) {
 	category.UpdatedAt = time.Now().UTC()
 	result, err := r.db.Exec(ctx,
-		"UPDATE categories SET name = $1, updated_at = $2 WHERE id = $3",
+		"UPDATE categories SET name = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL",
 		category.Name, category.UpdatedAt, category.ID)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating category: %v", category)
 }
 
 func (r *CategoryRepository) Delete(ctx context.Context, id uint64) (bool, error) {
-	result, err := r.db.Exec(ctx, "DELETE FROM categories WHERE id = $1", id)
+	result, err := r.db.Exec(ctx, "UPDATE categories SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", time.Now().UTC(), id)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting category with id: %d", id)
 }
+
+func (r *CategoryRepository) ReadDeleted(ctx context.Context) ([]*Category, error) {
+	categories := make([]*Category, 0)
+	err := r.db.Select(ctx, &categories,
+		"SELECT id, name, created_at, updated_at, deleted_at FROM categories WHERE deleted_at IS NOT NULL")
+	return categories, errors.Wrap(err, "error getting deleted categories")
+}
+
+func (r *CategoryRepository) Restore(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "UPDATE categories SET deleted_at = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL", time.Now().UTC(), id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error restoring category with id: %d", id)
+}
+
+func (r *CategoryRepository) Purge(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM categories WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if repository.IsForeignKeyViolation(err) {
+		return false, CategoryInUseErr
+	}
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error purging category with id: %d", id)
+}
@@ -12,6 +12,9 @@ type Repository interface {
 	ReadAll(ctx context.Context) ([]*Category, error)
 	Update(ctx context.Context, category *Category) (bool, error)
 	Delete(ctx context.Context, id uint64) (bool, error)
+	ReadDeleted(ctx context.Context) ([]*Category, error)
+	Restore(ctx context.Context, id uint64) (bool, error)
+	Purge(ctx context.Context, id uint64) (bool, error)
 }
 
 type CategoryService struct {
@@ -75,3 +78,37 @@ func (s *CategoryService) Delete(c echo.Context, id uint64) (bool, error) {
 
 	return isDeleted, nil
 }
+
+func (s *CategoryService) ReadDeleted(c echo.Context) ([]*DTO, error) {
+	categories, err := s.repository.ReadDeleted(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(categories) == 0 {
+		return nil, CategoryNotFoundErr
+	}
+
+	return ToDTOs(categories), nil
+}
+
+func (s *CategoryService) Restore(c echo.Context, id uint64) (bool, error) {
+	isRestored, err := s.repository.Restore(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isRestored, nil
+}
+
+func (s *CategoryService) Purge(c echo.Context, id uint64) (bool, error) {
+	isPurged, err := s.repository.Purge(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isPurged, nil
+}
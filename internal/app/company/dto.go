@@ -1,8 +1,11 @@
 package company
 
+import "time"
+
 type DTO struct {
-	ID   uint64 `json:"id,omitempty"`
-	Name string `json:"name,omitempty"`
+	ID        uint64     `json:"id,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 func (d *DTO) ToCompany() *Company {
@@ -5,4 +5,5 @@ import "errors"
 var (
 	CompanyNotFoundErr      = errors.New("компания не найдена")
 	CompanyAlreadyExistsErr = errors.New("компания с таким именем уже сушествует")
+	CompanyInUseErr         = errors.New("компания используется товарами и не может быть удалена окончательно")
 )
@@ -1,6 +1,7 @@
 package company
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -14,6 +15,9 @@ type Service interface {
 	ReadAll(c echo.Context) ([]*DTO, error)
 	Update(c echo.Context, companyDTO *DTO) (bool, error)
 	Delete(c echo.Context, id uint64) (bool, error)
+	ReadDeleted(c echo.Context) ([]*DTO, error)
+	Restore(c echo.Context, id uint64) (bool, error)
+	Purge(c echo.Context, id uint64) (bool, error)
 }
 
 type Handler struct {
@@ -146,3 +150,84 @@ func (h *Handler) Delete(c echo.Context) error {
 		"message": "компания была успешно удалена",
 	})
 }
+
+func (h *Handler) ReadDeleted(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	companyDTOs, err := h.service.ReadDeleted(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":      http.StatusOK,
+		"companies": companyDTOs,
+	})
+}
+
+func (h *Handler) Restore(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id компании")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id компании должно быть положительным")
+	}
+
+	isRestored, err := h.service.Restore(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isRestored {
+		return echo.NewHTTPError(http.StatusNotFound, CompanyNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "компания была успешно восстановлена",
+	})
+}
+
+func (h *Handler) Purge(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id компании")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id компании должно быть положительным")
+	}
+
+	isPurged, err := h.service.Purge(c, id)
+	if err != nil {
+		if errors.Is(err, CompanyInUseErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isPurged {
+		return echo.NewHTTPError(http.StatusNotFound, CompanyNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "компания была успешно удалена окончательно",
+	})
+}
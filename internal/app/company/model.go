@@ -3,16 +3,18 @@ package company
 import "time"
 
 type Company struct {
-	ID        uint64    `db:"id"`
-	Name      string    `db:"name"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID        uint64     `db:"id"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
 }
 
 func (c *Company) ToDTO() *DTO {
 	return &DTO{
-		ID:   c.ID,
-		Name: c.Name,
+		ID:        c.ID,
+		Name:      c.Name,
+		DeletedAt: c.DeletedAt,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -36,29 +37,49 @@ func (r *CompanyRepository) Create(ctx context.Context, company *Company) (uint6
 
 func (r *CompanyRepository) Read(ctx context.Context, id uint64) (*Company, error) {
 	var c Company
-	err := r.db.Get(ctx, &c, "SELECT id,name,created_at,updated_at FROM companies WHERE id = $1", id)
+	err := r.db.Get(ctx, &c, "SELECT id,name,created_at,updated_at FROM companies WHERE id = $1 AND deleted_at IS NULL", id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, CompanyNotFoundErr
 	}
-	return &c, nil
+	return &c, errors.Wrapf(err, "error getting company with id: %d", id)
 }
 
 func (r *CompanyRepository) ReadAll(ctx context.Context) ([]*Company, error) {
 	companies := make([]*Company, 0)
 	err := r.db.Select(ctx, &companies,
-		"SELECT id, name, created_at, updated_at FROM companies")
+		"SELECT id, name, created_at, updated_at FROM companies WHERE deleted_at IS NULL")
 	return companies, errors.Wrap(err, "error getting companies")
 }
 
 func (r *CompanyRepository) Update(ctx context.Context, company *Company) (bool, error) {
 	company.UpdatedAt = time.Now().UTC()
 	result, err := r.db.Exec(ctx,
-		"UPDATE companies SET name = $1, updated_at = $2 WHERE id = $3",
+		"UPDATE companies SET name = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL",
 		company.Name, company.UpdatedAt, company.ID)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating company: %v", company)
 }
 
 func (r *CompanyRepository) Delete(ctx context.Context, id uint64) (bool, error) {
-	result, err := r.db.Exec(ctx, "DELETE FROM companies WHERE id = $1", id)
+	result, err := r.db.Exec(ctx, "UPDATE companies SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", time.Now().UTC(), id)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting company with id: %d", id)
 }
+
+func (r *CompanyRepository) ReadDeleted(ctx context.Context) ([]*Company, error) {
+	companies := make([]*Company, 0)
+	err := r.db.Select(ctx, &companies,
+		"SELECT id, name, created_at, updated_at, deleted_at FROM companies WHERE deleted_at IS NOT NULL")
+	return companies, errors.Wrap(err, "error getting deleted companies")
+}
+
+func (r *CompanyRepository) Restore(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "UPDATE companies SET deleted_at = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL", time.Now().UTC(), id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error restoring company with id: %d", id)
+}
+
+func (r *CompanyRepository) Purge(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM companies WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if repository.IsForeignKeyViolation(err) {
+		return false, CompanyInUseErr
+	}
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error purging company with id: %d", id)
+}
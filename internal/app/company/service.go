@@ -12,6 +12,9 @@ type Repository interface {
 	ReadAll(ctx context.Context) ([]*Company, error)
 	Update(ctx context.Context, company *Company) (bool, error)
 	Delete(ctx context.Context, id uint64) (bool, error)
+	ReadDeleted(ctx context.Context) ([]*Company, error)
+	Restore(ctx context.Context, id uint64) (bool, error)
+	Purge(ctx context.Context, id uint64) (bool, error)
 }
 
 type CompanyService struct {
@@ -77,3 +80,37 @@ func (s *CompanyService) Delete(c echo.Context, id uint64) (bool, error) {
 
 	return isDeleted, nil
 }
+
+func (s *CompanyService) ReadDeleted(c echo.Context) ([]*DTO, error) {
+	companies, err := s.repository.ReadDeleted(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(companies) == 0 {
+		return nil, CompanyNotFoundErr
+	}
+
+	return ToDTOs(companies), nil
+}
+
+func (s *CompanyService) Restore(c echo.Context, id uint64) (bool, error) {
+	isRestored, err := s.repository.Restore(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isRestored, nil
+}
+
+func (s *CompanyService) Purge(c echo.Context, id uint64) (bool, error) {
+	isPurged, err := s.repository.Purge(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isPurged, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -16,12 +17,47 @@ type Config struct {
 	ApiPort            string `env:"API_PORT"`
 	ApiHost            string `env:"API_HOST"`
 	OuterClientAddress string `env:"OUTER_ADDRESS"`
+	OuterApiAddress    string `env:"OUTER_API_ADDRESS" env-required:"true"`
 	ClientHost         string `env:"CLIENT_HOST"`
 	ClientPort         string `env:"CLIENT_PORT"`
 	Email              string `env:"MAIL_EMAIL"`
 	MailHost           string `env:"MAIL_HOST"`
 	MailPort           int    `env:"MAIL_PORT"`
 	MailPassword       string `env:"MAIL_PASSWORD"`
+
+	// DevMode enables tools for local development such as the mock payment provider
+	DevMode bool `env:"DEV_MODE" env-default:"false"`
+
+	PaymentProvider            string        `env:"PAYMENT_PROVIDER" env-required:"true"`
+	PaymentWebhookSecret       string        `env:"PAYMENT_WEBHOOK_SECRET" env-required:"true"`
+	PaymentRefundRetryInterval time.Duration `env:"PAYMENT_REFUND_RETRY_INTERVAL" env-default:"5m"`
+
+	TaxInclusive bool `env:"TAX_INCLUSIVE" env-default:"true"`
+
+	CurrencyRatesSource string        `env:"CURRENCY_RATES_SOURCE" env-default:"file"`
+	CurrencyRatesPath   string        `env:"CURRENCY_RATES_PATH" env-default:"configs/currency_rates.json"`
+	CurrencyRatesURL    string        `env:"CURRENCY_RATES_URL"`
+	CurrencyRatesTTL    time.Duration `env:"CURRENCY_RATES_TTL" env-default:"1h"`
+
+	GuestCartSecret     string        `env:"GUEST_CART_SECRET" env-required:"true"`
+	GuestCartTTL        time.Duration `env:"GUEST_CART_TTL" env-default:"720h"`
+	GuestCartGCInterval time.Duration `env:"GUEST_CART_GC_INTERVAL" env-default:"1h"`
+
+	ReservationTTL           time.Duration `env:"RESERVATION_TTL" env-default:"15m"`
+	ReservationSweepInterval time.Duration `env:"RESERVATION_SWEEP_INTERVAL" env-default:"1m"`
+
+	CartReminderSecret   string        `env:"CART_REMINDER_SECRET" env-required:"true"`
+	CartReminderIdle     time.Duration `env:"CART_REMINDER_IDLE" env-default:"24h"`
+	CartReminderWindow   time.Duration `env:"CART_REMINDER_WINDOW" env-default:"168h"`
+	CartReminderInterval time.Duration `env:"CART_REMINDER_INTERVAL" env-default:"1h"`
+
+	WishlistAlertInterval time.Duration `env:"WISHLIST_ALERT_INTERVAL" env-default:"5m"`
+
+	IdempotencyKeyTTL        time.Duration `env:"IDEMPOTENCY_KEY_TTL" env-default:"24h"`
+	IdempotencyLeaseTTL      time.Duration `env:"IDEMPOTENCY_LEASE_TTL" env-default:"1m"`
+	IdempotencyCleanInterval time.Duration `env:"IDEMPOTENCY_CLEAN_INTERVAL" env-default:"1h"`
+
+	GiftDeliveryInterval time.Duration `env:"GIFT_DELIVERY_INTERVAL" env-default:"5m"`
 }
 
 func GetConfig() *Config {
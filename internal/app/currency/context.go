@@ -0,0 +1,46 @@
+package currency
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const Header = "X-Currency"
+
+type contextKey struct{}
+
+// Middleware reads the display currency from the currency query param or the X-Currency header
+// and puts it into the request context, unknown currencies are rejected
+func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		code := c.QueryParam("currency")
+		if code == "" {
+			code = c.Request().Header.Get(Header)
+		}
+		if code == "" {
+			return next(c)
+		}
+
+		cur, err := Lookup(code)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		c.SetRequest(c.Request().WithContext(WithCode(c.Request().Context(), cur.Code)))
+		return next(c)
+	}
+}
+
+func WithCode(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, contextKey{}, code)
+}
+
+// FromContext returns the display currency of the request, the base currency when none was requested
+func FromContext(ctx context.Context) string {
+	if code, ok := ctx.Value(contextKey{}).(string); ok {
+		return code
+	}
+	return Base
+}
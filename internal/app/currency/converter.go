@@ -0,0 +1,158 @@
+package currency
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Quote is the currency amounts are shown in and the rate they are converted by
+type Quote struct {
+	Currency string
+	Rate     float64
+}
+
+func BaseQuote() *Quote {
+	return &Quote{Currency: Base, Rate: 1}
+}
+
+func (q *Quote) Convert(amount uint64) uint64 {
+	return Convert(amount, q.Rate, q.Currency)
+}
+
+// Converter caches rates of the source and reloads them once they are older than ttl.
+// Stale rates are used when the source fails or while the rates are reloaded, so a slow or failing rates service
+// doesn't stop the store. Only one reload runs at a time and the source is never called under the lock
+type Converter struct {
+	source   Source
+	ttl      time.Duration
+	mu       sync.Mutex
+	rates    map[string]float64
+	loadedAt time.Time
+	loading  chan struct{}
+}
+
+func NewConverter(source Source, ttl time.Duration) *Converter {
+	return &Converter{source: source, ttl: ttl}
+}
+
+// Rate returns the price of one base currency unit in the currency
+func (c *Converter) Rate(ctx context.Context, code string) (float64, error) {
+	cur, err := Lookup(code)
+	if err != nil {
+		return 0, err
+	}
+	if cur.Code == Base {
+		return 1, nil
+	}
+
+	rates, err := c.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rate, ok := rates[cur.Code]
+	if !ok || rate <= 0 {
+		return 0, RateNotFoundErr
+	}
+	return rate, nil
+}
+
+// Quote returns the quote of the currency requested by the client, see Middleware
+func (c *Converter) Quote(ctx context.Context) (*Quote, error) {
+	code := FromContext(ctx)
+	rate, err := c.Rate(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &Quote{Currency: code, Rate: rate}, nil
+}
+
+// DisplayQuote returns the quote of the requested currency, or of the base currency when its rate is unavailable.
+// Amounts carry their currency code, so clients always know which currency they got
+func (c *Converter) DisplayQuote(ctx context.Context) *Quote {
+	q, err := c.Quote(ctx)
+	if err != nil {
+		log.Printf("showing amounts in %s instead of %s: %v", Base, FromContext(ctx), err)
+		return BaseQuote()
+	}
+	return q
+}
+
+// Rates returns rates of every known currency available in the source
+func (c *Converter) Rates(ctx context.Context) (map[string]float64, error) {
+	rates, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]float64{Base: 1}
+	for code, rate := range rates {
+		if _, err = Lookup(code); err == nil && rate > 0 {
+			known[code] = rate
+		}
+	}
+	return known, nil
+}
+
+func (c *Converter) load(ctx context.Context) (map[string]float64, error) {
+	c.mu.Lock()
+	if c.rates != nil && time.Since(c.loadedAt) < c.ttl {
+		defer c.mu.Unlock()
+		return c.rates, nil
+	}
+
+	if loading := c.loading; loading != nil {
+		rates := c.rates
+		c.mu.Unlock()
+		if rates != nil {
+			return rates, nil
+		}
+
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, RatesUnavailableErr
+		}
+		return c.cached()
+	}
+
+	loading := make(chan struct{})
+	c.loading = loading
+	c.mu.Unlock()
+
+	rates, err := c.source.Load(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loading = nil
+	close(loading)
+
+	if err != nil {
+		if c.rates != nil {
+			log.Printf("using currency rates loaded at %s: %v", c.loadedAt.Format(time.RFC3339), err)
+			return c.rates, nil
+		}
+		log.Printf("error loading currency rates: %v", err)
+		return nil, RatesUnavailableErr
+	}
+
+	c.rates = make(map[string]float64, len(rates.Rates))
+	for code, rate := range rates.Rates {
+		c.rates[Normalize(code)] = rate
+	}
+	c.loadedAt = time.Now()
+	return c.rates, nil
+}
+
+// cached returns the rates loaded by another request
+func (c *Converter) cached() (map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rates == nil {
+		return nil, RatesUnavailableErr
+	}
+	return c.rates, nil
+}
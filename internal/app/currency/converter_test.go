@@ -0,0 +1,120 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubSource returns its rates or its error and counts the loads
+type stubSource struct {
+	rates map[string]float64
+	err   error
+	loads int
+}
+
+func (s *stubSource) Load(_ context.Context) (*Rates, error) {
+	s.loads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Rates{Base: Base, Rates: s.rates}, nil
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount uint64
+		rate   float64
+		code   string
+		want   uint64
+	}{
+		{name: "base currency", amount: 12345, rate: 2, code: "RUB", want: 12345},
+		{name: "unknown currency", amount: 12345, rate: 2, code: "XXX", want: 12345},
+		{name: "two digit currency", amount: 10000, rate: 0.011, code: "USD", want: 110},
+		{name: "rounds half away from zero", amount: 50, rate: 0.01, code: "EUR", want: 1},
+		{name: "rounds down", amount: 49, rate: 0.01, code: "EUR", want: 0},
+		{name: "currency without minor units", amount: 10000, rate: 1.6, code: "JPY", want: 160},
+		{name: "lowercase code", amount: 10000, rate: 0.011, code: "usd", want: 110},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.amount, tt.rate, tt.code); got != tt.want {
+				t.Errorf("Convert() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterRate(t *testing.T) {
+	rates := map[string]float64{"usd": 0.011, "EUR": 0.01, "KZT": 0}
+
+	tests := []struct {
+		name    string
+		source  *stubSource
+		code    string
+		want    float64
+		wantErr error
+	}{
+		{name: "base currency", source: &stubSource{err: errors.New("down")}, code: "RUB", want: 1},
+		{name: "known currency", source: &stubSource{rates: rates}, code: "EUR", want: 0.01},
+		{name: "source codes are normalized", source: &stubSource{rates: rates}, code: " Usd", want: 0.011},
+		{name: "unknown currency", source: &stubSource{rates: rates}, code: "XXX", wantErr: UnknownCurrencyErr},
+		{name: "missing rate", source: &stubSource{rates: rates}, code: "JPY", wantErr: RateNotFoundErr},
+		{name: "zero rate", source: &stubSource{rates: rates}, code: "KZT", wantErr: RateNotFoundErr},
+		{name: "source fails", source: &stubSource{err: errors.New("down")}, code: "USD", wantErr: RatesUnavailableErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConverter(tt.source, time.Hour).Rate(context.Background(), tt.code)
+			if err != tt.wantErr {
+				t.Fatalf("Rate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Rate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterReload(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		failAfter bool
+		want      float64
+		wantLoads int
+	}{
+		{name: "fresh rates are cached", ttl: time.Hour, want: 0.01, wantLoads: 1},
+		{name: "expired rates are reloaded", ttl: 0, want: 0.02, wantLoads: 2},
+		{name: "stale rates are used when source fails", ttl: 0, failAfter: true, want: 0.01, wantLoads: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &stubSource{rates: map[string]float64{"EUR": 0.01}}
+			c := NewConverter(source, tt.ttl)
+			if _, err := c.Rate(context.Background(), "EUR"); err != nil {
+				t.Fatalf("Rate() error = %v", err)
+			}
+
+			source.rates = map[string]float64{"EUR": 0.02}
+			if tt.failAfter {
+				source.err = errors.New("down")
+			}
+			got, err := c.Rate(context.Background(), "EUR")
+			if err != nil {
+				t.Fatalf("Rate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Rate() = %v, want %v", got, tt.want)
+			}
+			if source.loads != tt.wantLoads {
+				t.Errorf("source loaded %d times, want %d", source.loads, tt.wantLoads)
+			}
+		})
+	}
+}
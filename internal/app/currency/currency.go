@@ -0,0 +1,64 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Base is the currency prices, totals and payments are stored in, all amounts are kept in its minor units
+const Base = "RUB"
+
+type Currency struct {
+	Code     string
+	Symbol   string
+	Exponent int
+}
+
+// currencies lists the currencies prices can be shown in, exponent is the number of minor unit digits
+var currencies = map[string]*Currency{
+	"RUB": {Code: "RUB", Symbol: "₽", Exponent: 2},
+	"USD": {Code: "USD", Symbol: "$", Exponent: 2},
+	"EUR": {Code: "EUR", Symbol: "€", Exponent: 2},
+	"KZT": {Code: "KZT", Symbol: "₸", Exponent: 2},
+	"BYN": {Code: "BYN", Symbol: "Br", Exponent: 2},
+	"CNY": {Code: "CNY", Symbol: "¥", Exponent: 2},
+	"JPY": {Code: "JPY", Symbol: "¥", Exponent: 0},
+}
+
+// Lookup returns the currency with the ISO 4217 code
+func Lookup(code string) (*Currency, error) {
+	c, ok := currencies[Normalize(code)]
+	if !ok {
+		return nil, UnknownCurrencyErr
+	}
+	return c, nil
+}
+
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Convert converts the amount in minor units of the base currency to minor units of the currency
+// by the rate of one base currency unit, the result is rounded half away from zero
+func Convert(amount uint64, rate float64, code string) uint64 {
+	to, err := Lookup(code)
+	if err != nil || to.Code == Base {
+		return amount
+	}
+	base := currencies[Base]
+	return uint64(math.Round(float64(amount) * rate * math.Pow10(to.Exponent-base.Exponent)))
+}
+
+// Format formats the amount in minor units of the currency, e.g. 1234.50 ₽
+func Format(amount uint64, code string) string {
+	c, err := Lookup(code)
+	if err != nil {
+		return fmt.Sprintf("%d %s", amount, code)
+	}
+	if c.Exponent == 0 {
+		return fmt.Sprintf("%d %s", amount, c.Symbol)
+	}
+	unit := uint64(math.Pow10(c.Exponent))
+	return fmt.Sprintf("%d.%0*d %s", amount/unit, c.Exponent, amount%unit, c.Symbol)
+}
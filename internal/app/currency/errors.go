@@ -0,0 +1,9 @@
+package currency
+
+import "errors"
+
+var (
+	UnknownCurrencyErr  = errors.New("неизвестная валюта")
+	RateNotFoundErr     = errors.New("курс валюты не найден")
+	RatesUnavailableErr = errors.New("курсы валют недоступны")
+)
@@ -0,0 +1,34 @@
+package currency
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Rates(ctx context.Context) (map[string]float64, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ReadRates returns the currencies prices can be shown in with the price of one base currency unit in them
+func (h *Handler) ReadRates(c echo.Context) error {
+	rates, err := h.service.Rates(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"base":  Base,
+		"rates": rates,
+	})
+}
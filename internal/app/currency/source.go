@@ -0,0 +1,85 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Rates holds how many units of every currency one unit of the base currency costs
+type Rates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Source loads the current conversion rates
+type Source interface {
+	Load(ctx context.Context) (*Rates, error)
+}
+
+// FileSource reads rates from a JSON file, it stands in for a rates service in development and tests
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Load(_ context.Context) (*Rates, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening currency rates file: %s", s.path)
+	}
+	defer f.Close()
+
+	return decodeRates(f)
+}
+
+// httpTimeout limits the rates request, so a hung rates service can't hold requests showing prices
+const httpTimeout = 10 * time.Second
+
+// HTTPSource fetches rates in the same JSON format from a rates service
+type HTTPSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{url: url, client: &http.Client{Timeout: httpTimeout}}
+}
+
+func (s *HTTPSource) Load(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating currency rates request: %s", s.url)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error requesting currency rates: %s", s.url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("currency rates service responded with status %d", resp.StatusCode)
+	}
+
+	return decodeRates(resp.Body)
+}
+
+func decodeRates(r io.Reader) (*Rates, error) {
+	rates := &Rates{}
+	if err := json.NewDecoder(r).Decode(rates); err != nil {
+		return nil, errors.Wrap(err, "error decoding currency rates")
+	}
+	if Normalize(rates.Base) != Base {
+		return nil, errors.Errorf("currency rates are given for %s instead of %s", rates.Base, Base)
+	}
+	return rates, nil
+}
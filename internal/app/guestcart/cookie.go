@@ -0,0 +1,29 @@
+package guestcart
+
+import (
+	"net/http"
+	"time"
+)
+
+const CookieName = "guest_cart"
+
+// cookie keeps the signed id of the cart, so visitors can't open carts of others by guessing their ids
+func cookie(token string, ttl time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(ttl),
+		HttpOnly: true,
+	}
+}
+
+func expiredCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Now().Add(-time.Hour),
+		HttpOnly: true,
+	}
+}
@@ -0,0 +1,20 @@
+package guestcart
+
+import "github.com/Mickey327/rcsp-backend/internal/app/product"
+
+type DTO struct {
+	Items    []*ItemDTO `json:"items"`
+	Count    uint64     `json:"count"`
+	Total    uint64     `json:"total"`
+	Currency string     `json:"currency"`
+}
+
+type ItemDTO struct {
+	Product  *product.DTO `json:"product"`
+	Quantity uint64       `json:"quantity"`
+}
+
+// UpdateDTO changes the quantity of the product in the cart by the given amount
+type UpdateDTO struct {
+	Quantity int64 `json:"quantity"`
+}
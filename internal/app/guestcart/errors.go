@@ -0,0 +1,9 @@
+package guestcart
+
+import "errors"
+
+var (
+	GuestCartNotFoundErr   = errors.New("гостевая корзина не найдена")
+	CartItemNotFoundErr    = errors.New("товар не найден в корзине")
+	NotPositiveQuantityErr = errors.New("пользователь не может сделать количество позиции менее 1")
+)
@@ -0,0 +1,96 @@
+package guestcart
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Read(c echo.Context) (*DTO, error)
+	UpdateItem(c echo.Context, productID uint64, delta int64) (*DTO, error)
+	DeleteItem(c echo.Context, productID uint64) (*DTO, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Read(c echo.Context) error {
+	cartDTO, err := h.service.Read(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"cart": cartDTO,
+	})
+}
+
+func (h *Handler) UpdateItem(c echo.Context) error {
+	productID, err := parseProductID(c)
+	if err != nil {
+		return err
+	}
+
+	updateDTO := UpdateDTO{}
+
+	if err = c.Bind(&updateDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	cartDTO, err := h.service.UpdateItem(c, productID, updateDTO.Quantity)
+	if err != nil {
+		if errors.Is(err, NotPositiveQuantityErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, product.ProductNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка произошла во время обновления корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"cart": cartDTO,
+	})
+}
+
+func (h *Handler) DeleteItem(c echo.Context) error {
+	productID, err := parseProductID(c)
+	if err != nil {
+		return err
+	}
+
+	cartDTO, err := h.service.DeleteItem(c, productID)
+	if err != nil {
+		if errors.Is(err, GuestCartNotFoundErr) || errors.Is(err, CartItemNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка произошла во время обновления корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"cart": cartDTO,
+	})
+}
+
+func parseProductID(c echo.Context) (uint64, error) {
+	productID, err := strconv.ParseUint(c.QueryParam("productID"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id товара")
+	}
+	if productID <= 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "id товара должно быть положительным")
+	}
+	return productID, nil
+}
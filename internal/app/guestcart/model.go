@@ -0,0 +1,48 @@
+package guestcart
+
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+)
+
+// Cart is built by a visitor who hasn't logged in, it is identified by a signed cookie
+type Cart struct {
+	ID        uint64    `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+	Items     []*Item
+}
+
+type Item struct {
+	CartID    uint64           `db:"cart_id"`
+	Quantity  uint64           `db:"quantity"`
+	CreatedAt time.Time        `db:"created_at"`
+	UpdatedAt time.Time        `db:"updated_at"`
+	Product   *product.Product `scan:"notate"`
+}
+
+// ToDTO shows the cart with the prices of the sales running now in the quoted currency
+func (c *Cart) ToDTO(q *currency.Quote) *DTO {
+	cartDTO := &DTO{
+		Items:    make([]*ItemDTO, 0, len(c.Items)),
+		Currency: q.Currency,
+	}
+
+	var total uint64
+	for _, item := range c.Items {
+		total += item.Quantity * item.Product.EffectivePrice
+		cartDTO.Count += item.Quantity
+
+		productDTO := item.Product.ToDTO()
+		productDTO.Convert(q)
+		cartDTO.Items = append(cartDTO.Items, &ItemDTO{
+			Product:  productDTO,
+			Quantity: item.Quantity,
+		})
+	}
+	cartDTO.Total = q.Convert(total)
+
+	return cartDTO
+}
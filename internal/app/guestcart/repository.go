@@ -0,0 +1,132 @@
+package guestcart
+
+import (
+	"context"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type GuestCartRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *GuestCartRepository {
+	return &GuestCartRepository{db: db}
+}
+
+func (r *GuestCartRepository) Create(ctx context.Context) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, "INSERT INTO guest_carts DEFAULT VALUES RETURNING id").Scan(&id)
+	return id, errors.Wrap(err, "error creating guest cart")
+}
+
+// ReadEager returns the cart with its items, items of archived products are hidden
+func (r *GuestCartRepository) ReadEager(ctx context.Context, id uint64) (*Cart, error) {
+	var cart Cart
+	err := r.db.Get(ctx, &cart, "SELECT id, created_at, updated_at FROM guest_carts WHERE id = $1", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, GuestCartNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting guest cart with id: %d", id)
+	}
+
+	cart.Items = make([]*Item, 0)
+	err = r.db.Select(ctx, &cart.Items, `
+		SELECT gi.cart_id, gi.quantity, gi.created_at, gi.updated_at,
+		       p.id as "product.id", p.name as "product.name", p.description as "product.description",
+		       p.price as "product.price", effective_price(p.id, NOW()) as "product.effective_price",
+		       p.stock as "product.stock", p.image as "product.image",
+		       p.category_id as "product.category.id", p.company_id as "product.company.id",
+		       p.created_at as "product.created_at", p.updated_at as "product.updated_at"
+		FROM guest_cart_items gi
+			JOIN products p ON p.id = gi.product_id
+		WHERE gi.cart_id = $1 AND p.deleted_at IS NULL
+		ORDER BY gi.created_at`, id)
+	return &cart, errors.Wrapf(err, "error getting items of guest cart with id: %d", id)
+}
+
+// UpdateItem changes the quantity of the product in the cart by the delta, adding the product when it isn't there yet
+func (r *GuestCartRepository) UpdateItem(ctx context.Context, cartID, productID uint64, delta int64) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO guest_cart_items(cart_id, product_id, quantity)
+		SELECT $1, products.id, $3 FROM products WHERE products.id = $2 AND products.deleted_at IS NULL
+		ON CONFLICT (cart_id, product_id) DO UPDATE
+		SET quantity = guest_cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()`,
+		cartID, productID, delta)
+	if repository.IsCheckViolation(err) {
+		return false, NotPositiveQuantityErr
+	}
+	if repository.IsForeignKeyViolation(err) {
+		return false, GuestCartNotFoundErr
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error updating product %d of guest cart with id: %d", productID, cartID)
+	}
+	if result.RowsAffected() == 0 {
+		return false, product.ProductNotFoundErr
+	}
+
+	return true, r.touch(ctx, cartID)
+}
+
+func (r *GuestCartRepository) DeleteItem(ctx context.Context, cartID, productID uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM guest_cart_items WHERE cart_id = $1 AND product_id = $2", cartID, productID)
+	if err != nil {
+		return false, errors.Wrapf(err, "error deleting product %d of guest cart with id: %d", productID, cartID)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	return true, r.touch(ctx, cartID)
+}
+
+// MergeInto moves items of the guest cart to the order. Products only the guest cart has keep their quantity.
+// Quantities of products both carts have are added up but not beyond the stock, and never drop below
+// the larger of the two quantities. Archived products are left behind
+func (r *GuestCartRepository) MergeInto(ctx context.Context, cartID, orderID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO order_items(order_id, product_id, quantity, seen_price)
+		SELECT $1, gi.product_id, gi.quantity, effective_price(gi.product_id, NOW())
+		FROM guest_cart_items gi
+			JOIN products p ON p.id = gi.product_id
+		WHERE gi.cart_id = $2 AND p.deleted_at IS NULL
+		ON CONFLICT (order_id, product_id) DO UPDATE
+		SET quantity = GREATEST(order_items.quantity, EXCLUDED.quantity,
+		                        LEAST(order_items.quantity + EXCLUDED.quantity,
+		                              (SELECT stock FROM products WHERE products.id = EXCLUDED.product_id))),
+		    updated_at = NOW()`,
+		orderID, cartID)
+	return errors.Wrapf(err, "error merging guest cart %d into order with id: %d", cartID, orderID)
+}
+
+func (r *GuestCartRepository) Delete(ctx context.Context, id uint64) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM guest_carts WHERE id = $1", id)
+	return errors.Wrapf(err, "error deleting guest cart with id: %d", id)
+}
+
+// DeleteAbandoned deletes carts which haven't changed for longer than ttl and returns their number
+func (r *GuestCartRepository) DeleteAbandoned(ctx context.Context, ttl time.Duration) (int64, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM guest_carts WHERE updated_at < NOW() - make_interval(secs => $1)", ttl.Seconds())
+	return result.RowsAffected(), errors.Wrap(err, "error deleting abandoned guest carts")
+}
+
+func (r *GuestCartRepository) touch(ctx context.Context, id uint64) error {
+	_, err := r.db.Exec(ctx, "UPDATE guest_carts SET updated_at = NOW() WHERE id = $1", id)
+	return errors.Wrapf(err, "error updating guest cart with id: %d", id)
+}
@@ -0,0 +1,205 @@
+package guestcart
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/signer"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context) (uint64, error)
+	ReadEager(ctx context.Context, id uint64) (*Cart, error)
+	UpdateItem(ctx context.Context, cartID, productID uint64, delta int64) (bool, error)
+	DeleteItem(ctx context.Context, cartID, productID uint64) (bool, error)
+	MergeInto(ctx context.Context, cartID, orderID uint64) error
+	Delete(ctx context.Context, id uint64) error
+	DeleteAbandoned(ctx context.Context, ttl time.Duration) (int64, error)
+}
+
+type OrderRepository interface {
+	ReadCurrentUserArrangingOrderEager(ctx context.Context, userID uint64) (*order.Order, error)
+}
+
+// Promotions recalculates the promo code discount of the cart the guest cart was merged into
+type Promotions interface {
+	Refresh(ctx context.Context, cart *order.Order) error
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type GuestCartService struct {
+	repository      Repository
+	orderRepository OrderRepository
+	promotions      Promotions
+	currencies      order.Currencies
+	transactor      Transactor
+	signer          *signer.Signer
+	ttl             time.Duration
+}
+
+// NewService creates the service of guest carts signed with the secret, carts unchanged for longer than ttl are abandoned
+func NewService(repository Repository, orderRepository OrderRepository, promotions Promotions, currencies order.Currencies,
+	transactor Transactor, secret string, ttl time.Duration) *GuestCartService {
+	return &GuestCartService{
+		repository:      repository,
+		orderRepository: orderRepository,
+		promotions:      promotions,
+		currencies:      currencies,
+		transactor:      transactor,
+		signer:          signer.New(secret, ""),
+		ttl:             ttl,
+	}
+}
+
+// Read returns the cart of the visitor, visitors without a cart get an empty one
+func (s *GuestCartService) Read(c echo.Context) (*DTO, error) {
+	cartID, ok := s.cartID(c)
+	if !ok {
+		return s.toDTO(c, &Cart{}), nil
+	}
+
+	cart, err := s.repository.ReadEager(c.Request().Context(), cartID)
+	if errors.Is(err, GuestCartNotFoundErr) {
+		c.SetCookie(expiredCookie())
+		return s.toDTO(c, &Cart{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+// UpdateItem changes the quantity of the product by the delta, the cart and its cookie are created on the first change.
+// Every change extends the cookie, as carts are abandoned only when they stay unchanged for ttl
+func (s *GuestCartService) UpdateItem(c echo.Context, productID uint64, delta int64) (*DTO, error) {
+	ctx := c.Request().Context()
+
+	cartID, ok := s.cartID(c)
+	if ok {
+		_, err := s.repository.UpdateItem(ctx, cartID, productID, delta)
+		if err == nil {
+			c.SetCookie(cookie(s.signer.Token(cartID), s.ttl))
+			return s.readCart(c, cartID)
+		}
+		// a cart collected as abandoned is started anew
+		if !errors.Is(err, GuestCartNotFoundErr) {
+			return nil, err
+		}
+	}
+
+	if delta <= 0 {
+		return nil, NotPositiveQuantityErr
+	}
+
+	err := s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if cartID, err = s.repository.Create(ctx); err != nil {
+			return err
+		}
+		_, err = s.repository.UpdateItem(ctx, cartID, productID, delta)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.SetCookie(cookie(s.signer.Token(cartID), s.ttl))
+
+	return s.readCart(c, cartID)
+}
+
+func (s *GuestCartService) DeleteItem(c echo.Context, productID uint64) (*DTO, error) {
+	cartID, ok := s.cartID(c)
+	if !ok {
+		return nil, GuestCartNotFoundErr
+	}
+
+	isDeleted, err := s.repository.DeleteItem(c.Request().Context(), cartID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !isDeleted {
+		return nil, CartItemNotFoundErr
+	}
+
+	return s.readCart(c, cartID)
+}
+
+// Merge moves the guest cart of the visitor who has just logged in or registered into the user's cart,
+// see GuestCartRepository.MergeInto for the quantity rules. The guest cart and its cookie are removed afterwards
+func (s *GuestCartService) Merge(c echo.Context, userID uint64) error {
+	cartID, ok := s.cartID(c)
+	if !ok {
+		return nil
+	}
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		cart, err := s.orderRepository.ReadCurrentUserArrangingOrderEager(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.repository.MergeInto(ctx, cartID, cart.ID); err != nil {
+			return err
+		}
+		if err = s.repository.Delete(ctx, cartID); err != nil {
+			return err
+		}
+
+		if cart.PromoCodeID == nil {
+			return nil
+		}
+		if cart, err = s.orderRepository.ReadCurrentUserArrangingOrderEager(ctx, userID); err != nil {
+			return err
+		}
+		return s.promotions.Refresh(ctx, cart)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(expiredCookie())
+	return nil
+}
+
+// CollectGarbage deletes guest carts abandoned for longer than ttl
+func (s *GuestCartService) CollectGarbage(ctx context.Context) error {
+	count, err := s.repository.DeleteAbandoned(ctx, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		log.Printf("deleted %d abandoned guest carts", count)
+	}
+	return nil
+}
+
+func (s *GuestCartService) cartID(c echo.Context) (uint64, bool) {
+	cookie, err := c.Cookie(CookieName)
+	if err != nil {
+		return 0, false
+	}
+	return s.signer.Verify(cookie.Value)
+}
+
+func (s *GuestCartService) readCart(c echo.Context, cartID uint64) (*DTO, error) {
+	cart, err := s.repository.ReadEager(c.Request().Context(), cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(c, cart), nil
+}
+
+// toDTO shows the cart in the currency requested by the client
+func (s *GuestCartService) toDTO(c echo.Context, cart *Cart) *DTO {
+	return cart.ToDTO(s.currencies.DisplayQuote(c.Request().Context()))
+}
@@ -0,0 +1,9 @@
+package idempotency
+
+import "errors"
+
+var (
+	KeyTooLongErr         = errors.New("ключ идемпотентности не должен быть длиннее 255 символов")
+	KeyInProgressErr      = errors.New("запрос с этим ключом идемпотентности еще выполняется")
+	KeyPayloadMismatchErr = errors.New("ключ идемпотентности уже использован для другого запроса")
+)
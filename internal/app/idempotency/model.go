@@ -0,0 +1,21 @@
+package idempotency
+
+import "time"
+
+// Key is a request made with the Idempotency-Key header and its response once the request is done
+type Key struct {
+	UserID      uint64    `db:"user_id"`
+	Key         string    `db:"key"`
+	Method      string    `db:"method"`
+	Path        string    `db:"path"`
+	RequestHash string    `db:"request_hash"`
+	StatusCode  *int      `db:"status_code"`
+	ContentType string    `db:"content_type"`
+	Response    []byte    `db:"response"`
+	LockedUntil time.Time `db:"locked_until"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (k *Key) IsDone() bool {
+	return k.StatusCode != nil
+}
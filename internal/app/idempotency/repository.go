@@ -0,0 +1,74 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type IdempotencyRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Begin stores the key of the request about to run and leases it to the request, false is returned if the key
+// has been used already. The key of the same request left without a response after its lease is taken over
+func (r *IdempotencyRepository) Begin(ctx context.Context, key *Key, lease time.Duration) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO idempotency_keys(user_id, key, method, path, request_hash, locked_until)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6))
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET locked_until = EXCLUDED.locked_until, created_at = NOW()
+		WHERE idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until < NOW()
+			AND idempotency_keys.method = EXCLUDED.method AND idempotency_keys.path = EXCLUDED.path
+			AND idempotency_keys.request_hash = EXCLUDED.request_hash`,
+		key.UserID, key.Key, key.Method, key.Path, key.RequestHash, lease.Seconds())
+	if err != nil {
+		return false, errors.Wrapf(err, "error storing idempotency key %q of user with id: %d", key.Key, key.UserID)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *IdempotencyRepository) Read(ctx context.Context, userID uint64, key string) (*Key, error) {
+	var k Key
+	err := r.db.Get(ctx, &k, `
+		SELECT user_id, key, method, path, request_hash, status_code, content_type, response, locked_until, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`, userID, key)
+	return &k, errors.Wrapf(err, "error getting idempotency key %q of user with id: %d", key, userID)
+}
+
+// Complete stores the response of the request made with the key
+func (r *IdempotencyRepository) Complete(ctx context.Context, key *Key) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE idempotency_keys SET status_code = $1, content_type = $2, response = $3
+		WHERE user_id = $4 AND key = $5`,
+		key.StatusCode, key.ContentType, key.Response, key.UserID, key.Key)
+	return errors.Wrapf(err, "error completing idempotency key %q of user with id: %d", key.Key, key.UserID)
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, userID uint64, key string) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2", userID, key)
+	return errors.Wrapf(err, "error deleting idempotency key %q of user with id: %d", key, userID)
+}
+
+// DeleteExpired deletes keys older than ttl and returns their number
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE created_at < NOW() - make_interval(secs => $1)", ttl.Seconds())
+	return result.RowsAffected(), errors.Wrap(err, "error deleting expired idempotency keys")
+}
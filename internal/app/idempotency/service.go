@@ -0,0 +1,179 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+)
+
+type Repository interface {
+	Begin(ctx context.Context, key *Key, lease time.Duration) (bool, error)
+	Read(ctx context.Context, userID uint64, key string) (*Key, error)
+	Complete(ctx context.Context, key *Key) error
+	Delete(ctx context.Context, userID uint64, key string) error
+	DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error)
+}
+
+// IdempotencyService makes repeats of state-changing requests of users harmless: a request sent again with
+// the same Idempotency-Key gets the response of the first one. Keys are kept for ttl, a running request
+// holds its key for lease, so keys of requests lost in a crash don't block repeats for the whole ttl
+type IdempotencyService struct {
+	repository Repository
+	ttl        time.Duration
+	lease      time.Duration
+}
+
+func NewService(repository Repository, ttl, lease time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		repository: repository,
+		ttl:        ttl,
+		lease:      lease,
+	}
+}
+
+// Middleware runs the request once per key of the user and replays the stored response for repeats.
+// The same key with another method, path, body or display currency is rejected, as well as a repeat of a request
+// still running. Responses of failed or panicked requests aren't stored, so such requests can be retried with
+// the same key. Keys are stored and released even if the client has gone, as the request has already run.
+// Requests without the header or without a logged in user are passed through
+func (s *IdempotencyService) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		keyValue := c.Request().Header.Get(Header)
+		if keyValue == "" {
+			return next(c)
+		}
+		if len(keyValue) > maxKeyLength {
+			return echo.NewHTTPError(http.StatusBadRequest, KeyTooLongErr.Error())
+		}
+
+		token, err := auth.GetUserToken(c)
+		if err != nil {
+			return next(c)
+		}
+		userData := auth.GetUserDataFromToken(token)
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "ошибка чтения тела запроса")
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		key := &Key{
+			UserID:      userData.ID,
+			Key:         keyValue,
+			Method:      c.Request().Method,
+			Path:        c.Request().URL.RequestURI(),
+			RequestHash: requestHash(c.Request(), body),
+		}
+
+		ctx := context.WithoutCancel(c.Request().Context())
+		isNew, err := s.repository.Begin(ctx, key, s.lease)
+		if err != nil {
+			log.Println("idempotency:", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "ошибка проверки ключа идемпотентности")
+		}
+		if !isNew {
+			return s.replay(ctx, c, key)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+		isStored := false
+		defer func() {
+			c.Response().Writer = recorder.ResponseWriter
+			if isStored {
+				return
+			}
+			if err := s.repository.Delete(ctx, key.UserID, key.Key); err != nil {
+				log.Println("idempotency:", err)
+			}
+		}()
+
+		if err = next(c); err != nil {
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		if status >= http.StatusInternalServerError {
+			return nil
+		}
+
+		// the key is kept even if it isn't completed, so the request doesn't run twice
+		isStored = true
+		key.StatusCode = &status
+		key.ContentType = c.Response().Header().Get(echo.HeaderContentType)
+		key.Response = recorder.body.Bytes()
+		if err = s.repository.Complete(ctx, key); err != nil {
+			log.Println("idempotency:", err)
+		}
+		return nil
+	}
+}
+
+// replay answers the repeated request with the response stored for its key
+func (s *IdempotencyService) replay(ctx context.Context, c echo.Context, key *Key) error {
+	stored, err := s.repository.Read(ctx, key.UserID, key.Key)
+	if err != nil {
+		log.Println("idempotency:", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка проверки ключа идемпотентности")
+	}
+
+	if stored.Method != key.Method || stored.Path != key.Path || stored.RequestHash != key.RequestHash {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, KeyPayloadMismatchErr.Error())
+	}
+	if !stored.IsDone() {
+		return echo.NewHTTPError(http.StatusConflict, KeyInProgressErr.Error())
+	}
+
+	c.Response().Header().Set(ReplayedHeader, "true")
+	return c.Blob(*stored.StatusCode, stored.ContentType, stored.Response)
+}
+
+// CollectGarbage deletes keys older than ttl, requests with such keys run again
+func (s *IdempotencyService) CollectGarbage(ctx context.Context) error {
+	count, err := s.repository.DeleteExpired(ctx, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		log.Printf("deleted %d expired idempotency keys", count)
+	}
+	return nil
+}
+
+// requestHash fingerprints the request, so another request can't reuse the key. The display currency
+// is a part of the request, as responses of the same request differ in prices
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write([]byte(currency.FromContext(r.Context()) + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies the response written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,62 @@
+package order
+
+import "github.com/Mickey327/rcsp-backend/internal/app/currency"
+
+// BundleLine is the bundle added to the order, its products are the order items and the order total
+// is lowered by the bundle savings. The price is the bundle price fixed at checkout or the current one for carts
+type BundleLine struct {
+	OrderID  uint64 `db:"order_id"`
+	BundleID uint64 `db:"bundle_id"`
+	Name     string `db:"name"`
+	Quantity uint64 `db:"quantity"`
+	Price    uint64 `db:"price"`
+}
+
+func (b *BundleLine) ToDTO() *BundleLineDTO {
+	return &BundleLineDTO{
+		BundleID: b.BundleID,
+		Name:     b.Name,
+		Quantity: b.Quantity,
+		Price:    b.Price,
+	}
+}
+
+func BundleLinesToDTOs(lines []*BundleLine) []*BundleLineDTO {
+	var lineDTOs []*BundleLineDTO
+
+	for _, line := range lines {
+		lineDTOs = append(lineDTOs, line.ToDTO())
+	}
+
+	return lineDTOs
+}
+
+type BundleLineDTO struct {
+	BundleID uint64 `json:"bundle_id"`
+	Name     string `json:"name"`
+	Quantity uint64 `json:"quantity"`
+	Price    uint64 `json:"price"`
+}
+
+func (d *BundleLineDTO) Convert(q *currency.Quote) {
+	d.Price = q.Convert(d.Price)
+}
+
+// Subtotal returns the sum of the order items at their prices before the bundle savings
+func (o *Order) Subtotal() uint64 {
+	var subtotal uint64
+	for _, item := range o.OrderItems {
+		if item.Quantity > 0 {
+			subtotal += uint64(item.Quantity) * item.Price
+		}
+	}
+	return subtotal
+}
+
+// BundleDiscount returns the savings of the bundles included in the total, the order has to be loaded with its items
+func (o *Order) BundleDiscount() uint64 {
+	if subtotal := o.Subtotal(); subtotal > o.Total {
+		return subtotal - o.Total
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+)
+
+func TestOrderBundleDiscount(t *testing.T) {
+	// a bundle of 2 items of 1000 and an item of 3000 bought separately
+	items := []*orderItem.OrderItem{
+		{Quantity: 2, Price: 1000},
+		{Quantity: 1, Price: 3000},
+		{Quantity: 0, Price: 5000},
+	}
+
+	tests := []struct {
+		name               string
+		order              *Order
+		wantSubtotal       uint64
+		wantBundleDiscount uint64
+	}{
+		{name: "without bundles", order: &Order{Total: 5000, OrderItems: items}, wantSubtotal: 5000},
+		{name: "with bundle savings", order: &Order{Total: 4500, OrderItems: items}, wantSubtotal: 5000, wantBundleDiscount: 500},
+		{name: "bundle pricier than products", order: &Order{Total: 5500, OrderItems: items}, wantSubtotal: 5000},
+		{name: "order without items", order: &Order{Total: 4500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.Subtotal(); got != tt.wantSubtotal {
+				t.Errorf("Subtotal() = %d, want %d", got, tt.wantSubtotal)
+			}
+			if got := tt.order.BundleDiscount(); got != tt.wantBundleDiscount {
+				t.Errorf("BundleDiscount() = %d, want %d", got, tt.wantBundleDiscount)
+			}
+		})
+	}
+}
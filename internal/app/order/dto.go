@@ -1,25 +1,115 @@
 package order
 
-import "github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+)
 
 type DTO struct {
-	ID         uint64           `json:"id"`
-	Total      uint64           `json:"total"`
-	Status     string           `json:"status"`
-	IsArranged bool             `json:"is_arranged"`
-	UserID     uint64           `json:"user_id"`
-	Count      uint64           `json:"count"`
-	OrderItems []*orderItem.DTO `json:"order_items"`
+	ID             uint64           `json:"id"`
+	Total          uint64           `json:"total"`
+	Discount       uint64           `json:"discount,omitempty"`
+	BundleDiscount uint64           `json:"bundle_discount,omitempty"`
+	PromoCodeID    *uint64          `json:"promo_code_id,omitempty"`
+	Refunded       uint64           `json:"refunded,omitempty"`
+	TaxTotal       uint64           `json:"tax_total,omitempty"`
+	TaxInclusive   bool             `json:"tax_inclusive"`
+	Taxes          []*TaxLineDTO    `json:"taxes,omitempty"`
+	Payable        uint64           `json:"payable"`
+	Currency       string           `json:"currency"`
+	Status         string           `json:"status"`
+	IsArranged     bool             `json:"is_arranged"`
+	CartName       *string          `json:"cart_name,omitempty"`
+	UserID         uint64           `json:"user_id"`
+	UserEmail      string           `json:"user_email,omitempty"`
+	Count          uint64           `json:"count"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	ArrangedAt     *time.Time       `json:"arranged_at,omitempty"`
+	OrderItems     []*orderItem.DTO `json:"order_items"`
+	Bundles        []*BundleLineDTO `json:"bundles,omitempty"`
+	Gift           *GiftDTO         `json:"gift,omitempty"`
 }
 
 func (d *DTO) ToOrder() *Order {
 	return &Order{
-		ID:         d.ID,
-		Total:      d.Total,
-		Status:     d.Status,
-		IsArranged: d.IsArranged,
-		UserID:     d.UserID,
-		Count:      d.Count,
-		OrderItems: orderItem.ToOrderItems(d.OrderItems),
+		ID:           d.ID,
+		Total:        d.Total,
+		Discount:     d.Discount,
+		PromoCodeID:  d.PromoCodeID,
+		Refunded:     d.Refunded,
+		TaxTotal:     d.TaxTotal,
+		TaxInclusive: d.TaxInclusive,
+		Currency:     d.Currency,
+		Status:       d.Status,
+		IsArranged:   d.IsArranged,
+		CartName:     d.CartName,
+		UserID:       d.UserID,
+		UserEmail:    d.UserEmail,
+		Count:        d.Count,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+		ArrangedAt:   d.ArrangedAt,
+		OrderItems:   orderItem.ToOrderItems(d.OrderItems),
 	}
 }
+
+// StatusUpdateDTO changes the status of the order, the gift can be given only with the checkout
+type StatusUpdateDTO struct {
+	ID      uint64   `json:"id"`
+	Status  string   `json:"status"`
+	Comment string   `json:"comment"`
+	Gift    *GiftDTO `json:"gift,omitempty"`
+}
+
+type StatusChangeDTO struct {
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	UserID     uint64    `json:"user_id,omitempty"`
+	Role       string    `json:"role"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type BulkStatusUpdateDTO struct {
+	IDs     []uint64 `json:"ids"`
+	Status  string   `json:"status"`
+	Comment string   `json:"comment"`
+}
+
+type BulkStatusResultDTO struct {
+	ID     uint64 `json:"id"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CancelDTO struct {
+	Reason string `json:"reason"`
+}
+
+// TaxLineDTO shows the tax of the order items with the same rate, rate is given in hundredths of a percent
+type TaxLineDTO struct {
+	Name   string `json:"name"`
+	Rate   uint64 `json:"rate"`
+	Base   uint64 `json:"base"`
+	Amount uint64 `json:"amount"`
+}
+
+// CartCheckDTO is valid when nothing has changed in the cart since the customer last saw it
+type CartCheckDTO struct {
+	Valid    bool            `json:"valid"`
+	Currency string          `json:"currency"`
+	Items    []*ItemCheckDTO `json:"items"`
+}
+
+type ItemCheckDTO struct {
+	ProductID        uint64 `json:"product_id"`
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	Quantity         uint64 `json:"quantity"`
+	PreviousQuantity uint64 `json:"previous_quantity"`
+	Price            uint64 `json:"price"`
+	PreviousPrice    uint64 `json:"previous_price"`
+	PriceChanged     bool   `json:"price_changed"`
+}
@@ -3,6 +3,53 @@ package order
 import "errors"
 
 var (
-	OrderNotFoundErr = errors.New("заказ не найден")
-	OrderEmptyErr    = errors.New("заказ пустой")
+	OrderNotFoundErr   = errors.New("заказ не найден")
+	OrderEmptyErr      = errors.New("заказ пустой")
+	ForeignOrderErr    = errors.New("пользователь не может изменить чужой заказ")
+	TransitionErr      = errors.New("заказ не может быть переведен в этот статус")
+	CancelReasonErr    = errors.New("необходимо указать причину отмены заказа")
+	NamedCartErr       = errors.New("товары именованной корзины нужно перенести в корзину, чтобы оформить заказ")
+	CartNameTakenErr   = errors.New("корзина с таким названием уже существует")
+	GiftNotAllowedErr  = errors.New("оформить заказ в подарок можно только при оформлении заказа")
+	GiftNotFoundErr    = errors.New("подарок не найден")
+	BundleNotInCartErr = errors.New("набора нет в корзине")
 )
+
+// StockShortage describes an order item which can't be fulfilled from the current stock
+type StockShortage struct {
+	ProductID uint64 `json:"product_id"`
+	Name      string `json:"name"`
+	Requested uint64 `json:"requested"`
+	Available uint64 `json:"available"`
+}
+
+type InsufficientStockErr struct {
+	Items []*StockShortage
+}
+
+func (e *InsufficientStockErr) Error() string {
+	return "недостаточно товара на складе"
+}
+
+// PromoCodeErr is returned by checkout when the promo code applied to the order can't be used anymore
+type PromoCodeErr struct {
+	Reason error
+}
+
+func (e *PromoCodeErr) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *PromoCodeErr) Unwrap() error {
+	return e.Reason
+}
+
+// CartChangedErr stops checkout when the cart has changed since the customer last saw it,
+// the cart is already corrected and the next checkout goes through
+type CartChangedErr struct {
+	Check *CartCheckDTO
+}
+
+func (e *CartChangedErr) Error() string {
+	return "корзина изменилась, проверьте ее перед оформлением заказа"
+}
@@ -0,0 +1,97 @@
+package order
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// sortColumns maps sort keys accepted from clients to order columns
+var sortColumns = map[string]string{
+	"id":     "orders.id",
+	"date":   "orders.arranged_at",
+	"total":  "orders.total",
+	"status": "orders.status",
+}
+
+// Filter describes a page of arranged orders
+type Filter struct {
+	UserID   uint64
+	Email    string
+	Statuses []string
+	From     *time.Time
+	To       *time.Time
+	MinTotal *uint64
+	MaxTotal *uint64
+	SortBy   string
+	Desc     bool
+	Page     uint64
+	Limit    uint64
+}
+
+// where builds the condition for orders joined with their users and the arguments for it
+func (f *Filter) where() (string, []interface{}) {
+	conditions := []string{"orders.is_arranged = true"}
+	args := make([]interface{}, 0)
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.UserID > 0 {
+		add("orders.user_id = $%d", f.UserID)
+	}
+	if f.Email != "" {
+		add("users.email ILIKE $%d", "%"+f.Email+"%")
+	}
+	if len(f.Statuses) > 0 {
+		add("orders.status = ANY($%d)", f.Statuses)
+	}
+	if f.From != nil {
+		add("orders.arranged_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("orders.arranged_at < $%d", *f.To)
+	}
+	if f.MinTotal != nil {
+		add("orders.total >= $%d", *f.MinTotal)
+	}
+	if f.MaxTotal != nil {
+		add("orders.total <= $%d", *f.MaxTotal)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func (f *Filter) orderBy() string {
+	column, ok := sortColumns[f.SortBy]
+	if !ok {
+		column = sortColumns["date"]
+	}
+
+	direction := "ASC"
+	if f.Desc {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("%s %s NULLS LAST, orders.id %s", column, direction, direction)
+}
+
+func (f *Filter) offset() uint64 {
+	if f.Page <= 1 {
+		return 0
+	}
+	return (f.Page - 1) * f.Limit
+}
+
+// IsSortable reports whether orders can be sorted by the key
+func IsSortable(key string) bool {
+	_, ok := sortColumns[key]
+	return ok
+}
@@ -0,0 +1,82 @@
+package order
+
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+)
+
+const (
+	GiftPending = "Ожидает отправки"
+	GiftSent    = "Отправлен"
+	GiftOpened  = "Открыт"
+)
+
+// Gift is the order bought for another person, the recipient gets it by email
+// on the delivery date or as soon as the order is paid if no date is set
+type Gift struct {
+	OrderID        uint64     `db:"order_id"`
+	RecipientEmail string     `db:"recipient_email"`
+	Message        string     `db:"message"`
+	DeliverAt      *time.Time `db:"deliver_at"`
+	Status         string     `db:"status"`
+	Token          string     `db:"token"`
+	SentAt         *time.Time `db:"sent_at"`
+	OpenedAt       *time.Time `db:"opened_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+func (g *Gift) ToDTO() *GiftDTO {
+	return &GiftDTO{
+		Email:     g.RecipientEmail,
+		Message:   g.Message,
+		DeliverAt: g.DeliverAt,
+		Status:    g.Status,
+		SentAt:    g.SentAt,
+		OpenedAt:  g.OpenedAt,
+	}
+}
+
+// GiftDTO is sent with the checkout to buy the order as a gift and shown to the buyer with the order
+type GiftDTO struct {
+	Email     string     `json:"email" validate:"required,email"`
+	Message   string     `json:"message,omitempty" validate:"max=1000"`
+	DeliverAt *time.Time `json:"deliver_at,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+}
+
+func (d *GiftDTO) ToGift() *Gift {
+	return &Gift{
+		RecipientEmail: d.Email,
+		Message:        d.Message,
+		DeliverAt:      d.DeliverAt,
+		Status:         GiftPending,
+	}
+}
+
+// GiftViewDTO is what the recipient sees opening the gift, prices of the order are not shown
+type GiftViewDTO struct {
+	Message string           `json:"message,omitempty"`
+	From    string           `json:"from"`
+	Items   []*orderItem.DTO `json:"items"`
+}
+
+func (o *Order) ToGiftView() *GiftViewDTO {
+	view := &GiftViewDTO{
+		From:  o.UserEmail,
+		Items: make([]*orderItem.DTO, 0, len(o.OrderItems)),
+	}
+	if o.Gift != nil {
+		view.Message = o.Gift.Message
+	}
+	for _, item := range o.OrderItems {
+		view.Items = append(view.Items, &orderItem.DTO{
+			Quantity: item.Quantity,
+			Name:     item.ProductName,
+			Image:    item.ProductImage,
+		})
+	}
+	return view
+}
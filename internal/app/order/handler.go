@@ -1,10 +1,15 @@
 package order
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
 	"github.com/labstack/echo/v4"
 )
 
@@ -13,7 +18,13 @@ type Service interface {
 	ReadCurrentUserArrangingOrderEager(c echo.Context, userID uint64) (*DTO, error)
 	Create(c echo.Context, userID uint64) (*DTO, error)
 	ReadByIdEager(c echo.Context, id uint64) (*DTO, error)
-	Update(c echo.Context, dto *DTO) (bool, error)
+	ReadArranged(c echo.Context, filter *Filter) ([]*DTO, uint64, error)
+	ReadStatusHistory(c echo.Context, orderID uint64) ([]*StatusChangeDTO, error)
+	Update(c echo.Context, dto *StatusUpdateDTO, actor *auth.UserData) (*DTO, error)
+	Cancel(c echo.Context, id uint64, dto *CancelDTO, actor *auth.UserData) (*DTO, error)
+	BulkUpdate(c echo.Context, dto *BulkStatusUpdateDTO, actor *auth.UserData) []*BulkStatusResultDTO
+	ValidateCart(c echo.Context, userID uint64) (*CartCheckDTO, error)
+	OpenGift(c echo.Context, token string) (*GiftViewDTO, error)
 }
 
 type Handler struct {
@@ -51,46 +62,374 @@ func (h *Handler) Update(c echo.Context) error {
 		return err
 	}
 
-	databaseOrderDTO, err := h.service.ReadCurrentUserArrangingOrderLazy(c, userData.ID)
+	statusDTO := StatusUpdateDTO{}
+
+	if err = c.Bind(&statusDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+	if statusDTO.Status == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+	if statusDTO.Gift != nil {
+		if err = c.Validate(statusDTO.Gift); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "данные подарка представлены в неверном формате")
+		}
+	}
+
+	if statusDTO.ID == 0 {
+		if userData.Role == "admin" {
+			return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+		}
+
+		databaseOrderDTO, err := h.service.ReadCurrentUserArrangingOrderLazy(c, userData.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		statusDTO.ID = databaseOrderDTO.ID
+	}
+
+	orderDTO, err := h.service.Update(c, &statusDTO, userData)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return orderUpdateError(c, err)
 	}
 
-	if databaseOrderDTO.Total == 0 {
-		return echo.NewHTTPError(http.StatusBadRequest, "пользователь не может обновить пустой заказ")
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": orderDTO,
+	})
+}
+
+func (h *Handler) Cancel(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user", "admin")
+
+	if err != nil {
+		return err
 	}
 
-	if databaseOrderDTO.UserID != userData.ID && userData.Role == "user" {
-		return echo.NewHTTPError(http.StatusForbidden, "пользователь не может обновить чужой заказ")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id заказа")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id заказа должно быть положительным")
 	}
 
-	orderDTO := DTO{}
+	cancelDTO := CancelDTO{}
 
-	if err = c.Bind(&orderDTO); err != nil {
+	if err = c.Bind(&cancelDTO); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
 	}
-	if orderDTO.Status == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+
+	orderDTO, err := h.service.Cancel(c, id, &cancelDTO, userData)
+	if err != nil {
+		if errors.Is(err, CancelReasonErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return orderUpdateError(c, err)
 	}
 
-	if userData.Role == "admin" && orderDTO.ID == 0 {
-		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"order": orderDTO,
+	})
+}
+
+func (h *Handler) ReadStatusHistory(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user", "admin")
+
+	if err != nil {
+		return err
 	}
 
-	databaseOrderDTO.Status = orderDTO.Status
-	databaseOrderDTO.IsArranged = true
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id заказа")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id заказа должно быть положительным")
+	}
 
-	isUpdated, err := h.service.Update(c, databaseOrderDTO)
-	if !isUpdated || err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка обновления заказа")
+	order, err := h.service.ReadByIdEager(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, OrderNotFoundErr.Error())
+	}
+
+	if order.UserID != userData.ID && userData.Role == "user" {
+		return echo.NewHTTPError(http.StatusForbidden, "пользователь не может получить чужой заказ")
+	}
+
+	history, err := h.service.ReadStatusHistory(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения истории заказа")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"history": history,
+	})
+}
+
+// ReadMine returns a page of the user's arranged orders, newest first.
+// Supports ?status (comma separated), ?from and ?to (YYYY-MM-DD or RFC3339), ?page and ?limit
+func (h *Handler) ReadMine(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.UserID = userData.ID
+	filter.Desc = true
+
+	orderDTOs, count, err := h.service.ReadArranged(c, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения заказов")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"orders": orderDTOs,
+		"count":  count,
+		"page":   filter.Page,
+		"limit":  filter.Limit,
+	})
+}
+
+// AdminReadAll returns a page of arranged orders of all users. Besides the filters of ReadMine supports
+// ?email, ?minTotal, ?maxTotal, ?sort (id, date, total, status) and ?order (asc, desc)
+func (h *Handler) AdminReadAll(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	filter.Email = c.QueryParam("email")
+
+	if filter.MinTotal, err = parseTotal(c.QueryParam("minTotal")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга минимальной суммы заказа")
+	}
+	if filter.MaxTotal, err = parseTotal(c.QueryParam("maxTotal")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга максимальной суммы заказа")
+	}
+
+	if sort := c.QueryParam("sort"); sort != "" {
+		if !IsSortable(sort) {
+			return echo.NewHTTPError(http.StatusBadRequest, "заказы нельзя отсортировать по полю "+sort)
+		}
+		filter.SortBy = sort
+	}
+
+	switch c.QueryParam("order") {
+	case "", "desc":
+		filter.Desc = true
+	case "asc":
+		filter.Desc = false
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "порядок сортировки должен быть asc или desc")
+	}
+
+	orderDTOs, count, err := h.service.ReadArranged(c, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения заказов")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"orders": orderDTOs,
+		"count":  count,
+		"page":   filter.Page,
+		"limit":  filter.Limit,
+	})
+}
+
+func (h *Handler) AdminRead(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id заказа")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id заказа должно быть положительным")
+	}
+
+	order, err := h.service.ReadByIdEager(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, OrderNotFoundErr.Error())
+	}
+
+	history, err := h.service.ReadStatusHistory(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения истории заказа")
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
 		"code":  http.StatusOK,
-		"order": databaseOrderDTO,
+		"order": order,
+		"customer": echo.Map{
+			"id":    order.UserID,
+			"email": order.UserEmail,
+		},
+		"history": history,
+	})
+}
+
+func (h *Handler) AdminBulkUpdate(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	bulkDTO := BulkStatusUpdateDTO{}
+
+	if err = c.Bind(&bulkDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+	if len(bulkDTO.IDs) == 0 || bulkDTO.Status == "" || len(bulkDTO.IDs) > MaxPageLimit {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+	// cancelled orders need a reason just like the single cancel
+	if bulkDTO.Status == StatusCancelled && strings.TrimSpace(bulkDTO.Comment) == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, CancelReasonErr.Error())
+	}
+
+	results := h.service.BulkUpdate(c, &bulkDTO, userData)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"results": results,
 	})
 }
 
+// parseFilter reads status, date range and pagination of the order list from the query
+func parseFilter(c echo.Context) (*Filter, error) {
+	filter := &Filter{
+		Page:  1,
+		Limit: DefaultPageLimit,
+	}
+
+	if statuses := c.QueryParam("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			status = strings.TrimSpace(status)
+			if !IsKnownStatus(status) {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, "неизвестный статус заказа: "+status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.From, err = parseDate(c.QueryParam("from"), false); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга начала периода")
+	}
+	if filter.To, err = parseDate(c.QueryParam("to"), true); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга конца периода")
+	}
+
+	if page := c.QueryParam("page"); page != "" {
+		filter.Page, err = strconv.ParseUint(page, 10, 64)
+		if err != nil || filter.Page <= 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "номер страницы должен быть положительным")
+		}
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		filter.Limit, err = strconv.ParseUint(limit, 10, 64)
+		if err != nil || filter.Limit <= 0 || filter.Limit > MaxPageLimit {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("размер страницы должен быть от 1 до %d", MaxPageLimit))
+		}
+	}
+
+	return filter, nil
+}
+
+// parseDate parses date or time from the query. A date as the end of a period includes the whole day
+func parseDate(value string, isEnd bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+func parseTotal(value string) (*uint64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	total, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &total, nil
+}
+
+// orderUpdateError converts errors of order status change to http responses
+func orderUpdateError(c echo.Context, err error) error {
+	var cartErr *CartChangedErr
+	if errors.As(err, &cartErr) {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"code":    http.StatusConflict,
+			"message": cartErr.Error(),
+			"cart":    cartErr.Check,
+		})
+	}
+	var stockErr *InsufficientStockErr
+	if errors.As(err, &stockErr) {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"code":    http.StatusConflict,
+			"message": stockErr.Error(),
+			"items":   stockErr.Items,
+		})
+	}
+	var promoErr *PromoCodeErr
+	if errors.As(err, &promoErr) {
+		return echo.NewHTTPError(http.StatusConflict, promoErr.Error())
+	}
+	if errors.Is(err, currency.RatesUnavailableErr) || errors.Is(err, currency.RateNotFoundErr) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+	if errors.Is(err, OrderNotFoundErr) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, ForeignOrderErr) {
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+	if errors.Is(err, OrderEmptyErr) || errors.Is(err, TransitionErr) || errors.Is(err, NamedCartErr) ||
+		errors.Is(err, GiftNotAllowedErr) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, "ошибка обновления заказа")
+}
+
 func (h *Handler) ReadByIdEager(c echo.Context) error {
 	userData, err := auth.GetUserDataAndCheckRole(c, "user", "admin")
 
@@ -147,3 +486,40 @@ func (h *Handler) ReadCurrentUserArrangingOrder(c echo.Context) error {
 		"order": orderDTO,
 	})
 }
+
+// ValidateCart reports what has changed in the user's cart since the user last saw it and corrects the cart
+func (h *Handler) ValidateCart(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	cartCheckDTO, err := h.service.ValidateCart(c, userData.ID)
+	if err != nil {
+		if errors.Is(err, OrderNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка проверки корзины")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"cart": cartCheckDTO,
+	})
+}
+
+// OpenGift is opened by the gift recipient from the email, so it doesn't need the user to be logged in
+func (h *Handler) OpenGift(c echo.Context) error {
+	giftDTO, err := h.service.OpenGift(c, c.Param("token"))
+	if err != nil {
+		if errors.Is(err, GiftNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения подарка")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"gift": giftDTO,
+	})
+}
@@ -3,31 +3,117 @@ package order
 import (
 	"time"
 
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
 	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
 )
 
 type Order struct {
-	ID         uint64    `db:"id"`
-	Total      uint64    `db:"total"`
-	Status     string    `db:"status"`
-	CreatedAt  time.Time `db:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at"`
-	IsArranged bool      `db:"is_arranged"`
-	UserID     uint64    `db:"user_id"`
-	Count      uint64
-	OrderItems []*orderItem.OrderItem `scan:"notate"`
+	ID           uint64     `db:"id"`
+	Total        uint64     `db:"total"`
+	Discount     uint64     `db:"discount"`
+	PromoCodeID  *uint64    `db:"promo_code_id"`
+	Refunded     uint64     `db:"refunded_total"`
+	TaxTotal     uint64     `db:"tax_total"`
+	TaxInclusive bool       `db:"tax_inclusive"`
+	Currency     string     `db:"currency"`
+	ExchangeRate float64    `db:"exchange_rate"`
+	Status       string     `db:"status"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+	ArrangedAt   *time.Time `db:"arranged_at"`
+	IsArranged   bool       `db:"is_arranged"`
+	CartName     *string    `db:"cart_name"`
+	UserID       uint64     `db:"user_id"`
+	UserEmail    string     `db:"user_email"`
+	Count        uint64
+	OrderItems   []*orderItem.OrderItem `scan:"notate"`
+	Taxes        []*TaxLine             `db:"-"`
+	Gift         *Gift                  `db:"-"`
+	Bundles      []*BundleLine          `db:"-"`
 }
 
+// ToDTO shows amounts of the order in its currency
 func (o *Order) ToDTO() *DTO {
-	return &DTO{
-		ID:         o.ID,
-		Total:      o.Total,
-		Status:     o.Status,
-		IsArranged: o.IsArranged,
-		UserID:     o.UserID,
-		Count:      o.Count,
-		OrderItems: orderItem.ToDTOs(o.OrderItems),
+	q := o.Quote()
+	orderDTO := &DTO{
+		ID:           o.ID,
+		Total:        q.Convert(o.Total),
+		Discount:     q.Convert(o.Discount),
+		PromoCodeID:  o.PromoCodeID,
+		Refunded:     q.Convert(o.Refunded),
+		TaxTotal:     q.Convert(o.TaxTotal),
+		TaxInclusive: o.TaxInclusive,
+		Taxes:        TaxLinesToDTOs(o.Taxes),
+		Payable:      o.Charge(),
+		Currency:     q.Currency,
+		Status:       o.Status,
+		IsArranged:   o.IsArranged,
+		CartName:     o.CartName,
+		UserID:       o.UserID,
+		UserEmail:    o.UserEmail,
+		Count:        o.Count,
+		CreatedAt:    o.CreatedAt,
+		UpdatedAt:    o.UpdatedAt,
+		ArrangedAt:   o.ArrangedAt,
+		OrderItems:   orderItem.ToDTOs(o.OrderItems),
+		Bundles:      BundleLinesToDTOs(o.Bundles),
+	}
+	if len(o.Bundles) > 0 {
+		orderDTO.BundleDiscount = q.Convert(o.BundleDiscount())
+	}
+	if o.Gift != nil {
+		orderDTO.Gift = o.Gift.ToDTO()
+	}
+	for _, line := range orderDTO.Taxes {
+		line.Base = q.Convert(line.Base)
+		line.Amount = q.Convert(line.Amount)
+	}
+	for _, item := range orderDTO.OrderItems {
+		item.Convert(q)
+	}
+	for _, line := range orderDTO.Bundles {
+		line.Convert(q)
+	}
+	return orderDTO
+}
+
+// Quote returns the currency the order is shown and paid in. Arranged orders keep the currency locked at checkout,
+// carts are shown in the currency the client asks for
+func (o *Order) Quote() *currency.Quote {
+	if o.Currency == "" || o.ExchangeRate <= 0 {
+		return currency.BaseQuote()
 	}
+	return &currency.Quote{Currency: o.Currency, Rate: o.ExchangeRate}
+}
+
+// SetQuote shows the order in the quoted currency
+func (o *Order) SetQuote(q *currency.Quote) {
+	o.Currency = q.Currency
+	o.ExchangeRate = q.Rate
+}
+
+// Format formats the amount in minor units of the base currency in the order currency
+func (o *Order) Format(amount uint64) string {
+	q := o.Quote()
+	return currency.Format(q.Convert(amount), q.Currency)
+}
+
+// Charge returns the payable amount in minor units of the order currency
+func (o *Order) Charge() uint64 {
+	return o.Quote().Convert(o.Payable())
+}
+
+// Payable returns the amount in minor units of the base currency the customer pays for the order, taxes are added unless prices include them.
+// Total of a cart may drop below its discount when a sale starts, the discount is recalculated at checkout
+func (o *Order) Payable() uint64 {
+	var payable uint64
+	if o.Discount < o.Total {
+		payable = o.Total - o.Discount
+	}
+	if !o.TaxInclusive {
+		payable += o.TaxTotal
+	}
+	return payable
 }
 
 func ToDTOs(orders []*Order) []*DTO {
@@ -39,3 +125,63 @@ func ToDTOs(orders []*Order) []*DTO {
 
 	return orderDTOs
 }
+
+type StatusChange struct {
+	ID         uint64    `db:"id"`
+	OrderID    uint64    `db:"order_id"`
+	FromStatus string    `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	UserID     uint64    `db:"user_id"`
+	Role       string    `db:"role_name"`
+	Comment    string    `db:"comment"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (s *StatusChange) ToDTO() *StatusChangeDTO {
+	return &StatusChangeDTO{
+		FromStatus: s.FromStatus,
+		ToStatus:   s.ToStatus,
+		UserID:     s.UserID,
+		Role:       s.Role,
+		Comment:    s.Comment,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func StatusChangesToDTOs(changes []*StatusChange) []*StatusChangeDTO {
+	var changeDTOs []*StatusChangeDTO
+
+	for _, change := range changes {
+		changeDTOs = append(changeDTOs, change.ToDTO())
+	}
+
+	return changeDTOs
+}
+
+// TaxLine is the tax of the order items taxed with the same rate
+type TaxLine struct {
+	OrderID uint64 `db:"order_id"`
+	Name    string `db:"name"`
+	Rate    uint64 `db:"rate"`
+	Base    uint64 `db:"base"`
+	Amount  uint64 `db:"amount"`
+}
+
+func (t *TaxLine) ToDTO() *TaxLineDTO {
+	return &TaxLineDTO{
+		Name:   t.Name,
+		Rate:   t.Rate,
+		Base:   t.Base,
+		Amount: t.Amount,
+	}
+}
+
+func TaxLinesToDTOs(lines []*TaxLine) []*TaxLineDTO {
+	var lineDTOs []*TaxLineDTO
+
+	for _, line := range lines {
+		lineDTOs = append(lineDTOs, line.ToDTO())
+	}
+
+	return lineDTOs
+}
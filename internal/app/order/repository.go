@@ -2,9 +2,11 @@ package order
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
 	"github.com/pkg/errors"
 
 	"github.com/jackc/pgx/v5"
@@ -20,6 +22,37 @@ type DB interface {
 	GetPool() *pgxpool.Pool
 }
 
+// eagerOrderItemsQuery selects order items with their products. Price, name and image are taken from the snapshot
+// made when the order was arranged and fall back to the live product for orders that are still being arranged,
+// whose prices include the sales running now.
+const eagerOrderItemsQuery = `
+		SELECT 
+		    order_items.quantity, order_items.order_id, order_items.created_at, order_items.updated_at,
+		    COALESCE(order_items.price, effective_price(p.id, NOW())) as price,
+		    COALESCE(order_items.product_name, p.name) as product_name,
+		    COALESCE(order_items.product_image, p.image) as product_image, order_items.refunded_quantity,
+		    p.id as "product.id", p.name as "product.name", p.description as "product.description", p.price as "product.price",
+		    effective_price(p.id, NOW()) as "product.effective_price", p.stock as "product.stock",
+        	p.image as "product.image", p.created_at as "product.created_at", p.updated_at as "product.updated_at",
+        	c.id as "product.category.id", c.name as "product.category.name", c.updated_at as "product.category.updated_at", c.created_at as "product.category.created_at",
+       		c2.id as "product.company.id", c2.name as "product.company.name", c2.updated_at as "product.company.updated_at", c2.created_at as "product.company.created_at"
+		FROM order_items
+			JOIN products p on p.id = order_items.product_id
+			JOIN categories c on p.category_id = c.id
+			JOIN companies c2 on p.company_id = c2.id
+		WHERE order_items.order_id = $1
+			`
+
+// totalColumn selects the order total. Totals of carts are recalculated on read, so sales started or ended
+// since the cart was changed are taken into account, totals of arranged orders are fixed at checkout
+const totalColumn = "CASE WHEN orders.is_arranged THEN orders.total ELSE order_total(orders.id) END as total"
+
+const orderColumns = "id, " + totalColumn + `, discount, promo_code_id, refunded_total, tax_total, tax_inclusive,
+		currency, exchange_rate, status, is_arranged, cart_name, user_id, created_at, updated_at, arranged_at`
+
+const giftSelect = `SELECT order_id, recipient_email, message, deliver_at, status, token, sent_at, opened_at, created_at
+		FROM order_gifts`
+
 type OrderRepository struct {
 	db DB
 }
@@ -32,7 +65,10 @@ func NewRepository(db DB) *OrderRepository {
 
 func (r *OrderRepository) Create(ctx context.Context, userID uint64) (*Order, error) {
 	var id uint64
-	err := r.db.ExecQueryRow(ctx, `INSERT INTO orders(status, user_id) VALUES ('Создан', $1) RETURNING id`, userID).Scan(&id)
+	err := r.db.ExecQueryRow(ctx, `INSERT INTO orders(status, user_id) VALUES ($1, $2) RETURNING id`, StatusCreated, userID).Scan(&id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating order for user with id: %v", userID)
+	}
 
 	order, err := r.ReadById(ctx, id)
 	if err != nil {
@@ -45,24 +81,216 @@ func (r *OrderRepository) Create(ctx context.Context, userID uint64) (*Order, er
 func (r *OrderRepository) Update(ctx context.Context, order *Order) (bool, error) {
 	order.UpdatedAt = time.Now().UTC()
 	result, err := r.db.Exec(ctx,
-		"UPDATE orders SET is_arranged = $1, status = $2, updated_at = $3 WHERE id = $4",
+		`UPDATE orders
+		SET is_arranged = $1, status = $2, updated_at = $3,
+		    arranged_at = CASE WHEN $1 AND arranged_at IS NULL THEN $3 ELSE arranged_at END
+		WHERE id = $4`,
 		order.IsArranged, order.Status, order.UpdatedAt, order.ID)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating order: %v", order)
 }
 
+// UpdateDiscount attaches the promo code to the order with the discount it gives, nil promo code detaches it
+func (r *OrderRepository) UpdateDiscount(ctx context.Context, orderID uint64, promoCodeID *uint64, discount uint64) error {
+	_, err := r.db.Exec(ctx, "UPDATE orders SET promo_code_id = $1, discount = $2, updated_at = NOW() WHERE id = $3",
+		promoCodeID, discount, orderID)
+	return errors.Wrapf(err, "error updating discount of order with id: %d", orderID)
+}
+
+// SaveTaxes replaces tax lines of the order and stores their sum in the order
+func (r *OrderRepository) SaveTaxes(ctx context.Context, order *Order) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM order_taxes WHERE order_id = $1", order.ID)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting taxes of order with id: %d", order.ID)
+	}
+
+	for _, line := range order.Taxes {
+		_, err = r.db.Exec(ctx, "INSERT INTO order_taxes(order_id, name, rate, base, amount) VALUES ($1, $2, $3, $4, $5)",
+			order.ID, line.Name, line.Rate, line.Base, line.Amount)
+		if err != nil {
+			return errors.Wrapf(err, "error creating tax of order with id: %d", order.ID)
+		}
+	}
+
+	_, err = r.db.Exec(ctx, "UPDATE orders SET tax_total = $1, tax_inclusive = $2, updated_at = NOW() WHERE id = $3",
+		order.TaxTotal, order.TaxInclusive, order.ID)
+	return errors.Wrapf(err, "error updating taxes of order with id: %d", order.ID)
+}
+
+// UpdateCurrency locks the currency of the order and its exchange rate
+func (r *OrderRepository) UpdateCurrency(ctx context.Context, order *Order) error {
+	_, err := r.db.Exec(ctx, "UPDATE orders SET currency = $1, exchange_rate = $2, updated_at = NOW() WHERE id = $3",
+		order.Currency, order.ExchangeRate, order.ID)
+	return errors.Wrapf(err, "error updating currency of order with id: %d", order.ID)
+}
+
+func (r *OrderRepository) readTaxes(ctx context.Context, orderID uint64) ([]*TaxLine, error) {
+	lines := make([]*TaxLine, 0)
+	err := r.db.Select(ctx, &lines, "SELECT order_id, name, rate, base, amount FROM order_taxes WHERE order_id = $1 ORDER BY id", orderID)
+	return lines, errors.Wrapf(err, "error getting taxes of order with id: %d", orderID)
+}
+
+func (r *OrderRepository) SaveGift(ctx context.Context, gift *Gift) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO order_gifts(order_id, recipient_email, message, deliver_at, status, token)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		gift.OrderID, gift.RecipientEmail, gift.Message, gift.DeliverAt, gift.Status, gift.Token)
+	return errors.Wrapf(err, "error saving gift of order with id: %d", gift.OrderID)
+}
+
+// readGift returns the gift of the order, nil if the order isn't a gift
+func (r *OrderRepository) readGift(ctx context.Context, orderID uint64) (*Gift, error) {
+	gifts := make([]*Gift, 0, 1)
+	err := r.db.Select(ctx, &gifts, giftSelect+" WHERE order_id = $1", orderID)
+	if err != nil || len(gifts) == 0 {
+		return nil, errors.Wrapf(err, "error getting gift of order with id: %d", orderID)
+	}
+	return gifts[0], nil
+}
+
+// ReadDueGifts returns gifts which haven't been sent yet while their orders are paid and their delivery date has come
+func (r *OrderRepository) ReadDueGifts(ctx context.Context) ([]*Gift, error) {
+	gifts := make([]*Gift, 0)
+	err := r.db.Select(ctx, &gifts, giftSelect+`
+		WHERE order_gifts.status = $1
+			AND (order_gifts.deliver_at IS NULL OR order_gifts.deliver_at <= NOW())
+			AND EXISTS (SELECT 1 FROM orders WHERE orders.id = order_gifts.order_id AND orders.status IN ($2, $3))
+		ORDER BY order_gifts.order_id`, GiftPending, StatusPaid, StatusDelivered)
+	return gifts, errors.Wrap(err, "error getting due gifts")
+}
+
+// ClaimGift marks the pending gift as sent before it's sent, false is returned if the gift has been claimed already
+func (r *OrderRepository) ClaimGift(ctx context.Context, orderID uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "UPDATE order_gifts SET status = $1, sent_at = NOW() WHERE order_id = $2 AND status = $3",
+		GiftSent, orderID, GiftPending)
+	if err != nil {
+		return false, errors.Wrapf(err, "error claiming gift of order with id: %d", orderID)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// OpenGift marks the sent gift as opened by the recipient and returns it, the first opening time is kept
+func (r *OrderRepository) OpenGift(ctx context.Context, token string) (*Gift, error) {
+	var gift Gift
+	err := r.db.Get(ctx, &gift, `
+		UPDATE order_gifts SET status = $1, opened_at = COALESCE(opened_at, NOW())
+		WHERE token = $2 AND status <> $3
+		RETURNING order_id, recipient_email, message, deliver_at, status, token, sent_at, opened_at, created_at`,
+		GiftOpened, token, GiftPending)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, GiftNotFoundErr
+	}
+	return &gift, errors.Wrap(err, "error opening gift")
+}
+
+// LockById reads the order and locks it until the end of the transaction
+func (r *OrderRepository) LockById(ctx context.Context, id uint64) (*Order, error) {
+	var o Order
+	err := r.db.Get(ctx, &o, "SELECT "+orderColumns+" FROM orders WHERE id = $1 FOR UPDATE", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, OrderNotFoundErr
+	}
+	return &o, errors.Wrapf(err, "error locking order with id: %d", id)
+}
+
+// LockOrderProducts reads order items with the current stock of their products and locks the products
+// until the end of the transaction. Products are locked in the same order to avoid deadlocks between checkouts.
+// The stock available to the order excludes reservations of other carts
+func (r *OrderRepository) LockOrderProducts(ctx context.Context, orderID uint64) ([]*orderItem.OrderItem, error) {
+	orderItems := make([]*orderItem.OrderItem, 0)
+	err := r.db.Select(ctx, &orderItems, `
+		SELECT 
+		    order_items.quantity, order_items.order_id, order_items.created_at, order_items.updated_at,
+		    p.id as "product.id", p.name as "product.name", p.price as "product.price", p.stock as "product.stock",
+		    available_stock(p.id, order_items.order_id) as "product.available",
+		    p.image as "product.image", p.deleted_at as "product.deleted_at"
+		FROM order_items
+			JOIN products p on p.id = order_items.product_id
+		WHERE order_items.order_id = $1
+		ORDER BY p.id
+		FOR UPDATE OF p`, orderID)
+	return orderItems, errors.Wrapf(err, "error locking products of order with id: %d", orderID)
+}
+
+// LockCartItemStates reads items of the cart with the current state of their products and locks the items
+// until the end of the transaction. Products of archived categories or companies are unavailable as well,
+// the stock excludes reservations of other carts
+func (r *OrderRepository) LockCartItemStates(ctx context.Context, orderID uint64) ([]*CartItemState, error) {
+	states := make([]*CartItemState, 0)
+	err := r.db.Select(ctx, &states, `
+		SELECT order_items.product_id, p.name, order_items.quantity, order_items.seen_price,
+		       effective_price(p.id, NOW()) as price, available_stock(p.id, order_items.order_id) as stock,
+		       p.deleted_at IS NULL AND c.deleted_at IS NULL AND c2.deleted_at IS NULL as available
+		FROM order_items
+			JOIN products p on p.id = order_items.product_id
+			JOIN categories c on p.category_id = c.id
+			JOIN companies c2 on p.company_id = c2.id
+		WHERE order_items.order_id = $1
+		ORDER BY order_items.created_at
+		FOR UPDATE OF order_items`, orderID)
+	return states, errors.Wrapf(err, "error locking items of cart with id: %d", orderID)
+}
+
+// RepriceItem sets the quantity of the cart item and remembers the price the customer has seen
+func (r *OrderRepository) RepriceItem(ctx context.Context, orderID, productID, quantity, seenPrice uint64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE order_items SET quantity = $1, seen_price = $2, updated_at = NOW()
+		WHERE order_id = $3 AND product_id = $4`, quantity, seenPrice, orderID, productID)
+	return errors.Wrapf(err, "error repricing product %d of cart with id: %d", productID, orderID)
+}
+
+func (r *OrderRepository) DeleteItem(ctx context.Context, orderID, productID uint64) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM order_items WHERE order_id = $1 AND product_id = $2", orderID, productID)
+	return errors.Wrapf(err, "error deleting product %d of cart with id: %d", productID, orderID)
+}
+
+func (r *OrderRepository) DecrementStock(ctx context.Context, orderID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE products
+		SET stock = products.stock - order_items.quantity
+		FROM order_items
+		WHERE products.id = order_items.product_id AND order_items.order_id = $1`, orderID)
+	return errors.Wrapf(err, "error decrementing stock for order with id: %d", orderID)
+}
+
+// RestoreStock returns quantities of all order items which weren't refunded back to the stock of their products
+func (r *OrderRepository) RestoreStock(ctx context.Context, orderID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE products
+		SET stock = products.stock + order_items.quantity - order_items.refunded_quantity
+		FROM order_items
+		WHERE products.id = order_items.product_id AND order_items.order_id = $1`, orderID)
+	return errors.Wrapf(err, "error restoring stock for order with id: %d", orderID)
+}
+
+// SnapshotOrderItems fixes current sale price, name and image of the products in the order items,
+// so later product changes don't rewrite the order. Already captured items are left untouched.
+func (r *OrderRepository) SnapshotOrderItems(ctx context.Context, orderID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE order_items
+		SET price = effective_price(products.id, NOW()), product_name = products.name, product_image = products.image
+		FROM products
+		WHERE products.id = order_items.product_id AND order_items.order_id = $1 AND order_items.price IS NULL`, orderID)
+	return errors.Wrapf(err, "error making snapshot of order items for order with id: %d", orderID)
+}
+
 func (r *OrderRepository) ReadCurrentUserArrangingOrderLazy(ctx context.Context, userID uint64) (*Order, error) {
 	var o Order
 
-	err := r.db.Get(ctx, &o, "SELECT id, total, status, is_arranged, user_id, created_at, updated_at FROM orders WHERE user_id = $1 AND is_arranged = false", userID)
+	err := r.db.Get(ctx, &o, "SELECT "+orderColumns+" FROM orders WHERE user_id = $1 AND is_arranged = false AND cart_name IS NULL", userID)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, OrderNotFoundErr
 	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting cart of user with id: %d", userID)
+	}
 
 	var count uint64
 	err = r.db.Get(ctx, &count, `
 			SELECT COUNT(*) 
 			FROM order_items 
-			WHERE order_id = (SELECT orders.id FROM orders WHERE orders.user_id = $1 AND is_arranged = false)`, userID)
+			WHERE order_id = (
+				SELECT orders.id FROM orders WHERE orders.user_id = $1 AND is_arranged = false AND cart_name IS NULL
+			)`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,28 +302,21 @@ func (r *OrderRepository) ReadCurrentUserArrangingOrderLazy(ctx context.Context,
 func (r *OrderRepository) ReadCurrentUserArrangingOrderEager(ctx context.Context, userID uint64) (*Order, error) {
 	var o Order
 	err := r.db.Get(ctx, &o, `
-		SELECT orders.id, orders.total, orders.status, orders.is_arranged, orders.user_id, orders.created_at, orders.updated_at
+		SELECT orders.id, `+totalColumn+`, orders.discount, orders.promo_code_id, orders.refunded_total, orders.tax_total,
+		       orders.tax_inclusive, orders.currency, orders.exchange_rate,
+		       orders.status, orders.is_arranged, orders.cart_name, orders.user_id,
+		       orders.created_at, orders.updated_at, orders.arranged_at
 		FROM orders
-		WHERE user_id = $1 AND is_arranged = false
+		WHERE user_id = $1 AND is_arranged = false AND cart_name IS NULL
 		`, userID)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, OrderNotFoundErr
 	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting cart of user with id: %d", userID)
+	}
 
-	orderItems := make([]*orderItem.OrderItem, 0)
-	err = r.db.Select(ctx, &orderItems, ` 
-		SELECT 
-		    order_items.quantity, order_items.order_id, order_items.created_at, order_items.updated_at,
-		    p.id as "product.id", p.name as "product.name", p.description as "product.description", p.price as "product.price", p.stock as "product.stock",
-        	p.image as "product.image", p.created_at as "product.created_at", p.updated_at as "product.updated_at",
-        	c.id as "product.category.id", c.name as "product.category.name", c.updated_at as "product.category.updated_at", c.created_at as "product.category.created_at",
-       		c2.id as "product.company.id", c2.name as "product.company.name", c2.updated_at as "product.company.updated_at", c2.created_at as "product.company.created_at"
-		FROM order_items
-			JOIN products p on p.id = order_items.product_id
-			JOIN categories c on p.category_id = c.id
-			JOIN companies c2 on p.company_id = c2.id
-		WHERE order_items.order_id = $1
-			`, o.ID)
+	orderItems, err := r.readOrderItems(ctx, o.ID)
 
 	o.OrderItems = orderItems
 	o.Count = uint64(len(orderItems))
@@ -104,41 +325,43 @@ func (r *OrderRepository) ReadCurrentUserArrangingOrderEager(ctx context.Context
 		return nil, err
 	}
 
+	o.Bundles, err = r.readBundles(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &o, nil
 }
 
 func (r *OrderRepository) ReadById(ctx context.Context, id uint64) (*Order, error) {
 	var o Order
-	err := r.db.Get(ctx, &o, "SELECT id, total, status, is_arranged, user_id, created_at, updated_at FROM orders WHERE id = $1", id)
+	err := r.db.Get(ctx, &o, "SELECT "+orderColumns+" FROM orders WHERE id = $1", id)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, OrderNotFoundErr
 	}
 
-	return &o, nil
+	return &o, errors.Wrapf(err, "error getting order with id: %d", id)
 }
 
 func (r *OrderRepository) ReadByIdEager(ctx context.Context, id uint64) (*Order, error) {
 	var o Order
-	err := r.db.Get(ctx, &o, "SELECT id, total, status, is_arranged, user_id, created_at, updated_at FROM orders WHERE id = $1", id)
+	err := r.db.Get(ctx, &o, `
+		SELECT orders.id, `+totalColumn+`, orders.discount, orders.promo_code_id, orders.refunded_total, orders.tax_total,
+		       orders.tax_inclusive, orders.currency, orders.exchange_rate,
+		       orders.status, orders.is_arranged, orders.cart_name, orders.user_id,
+		       orders.created_at, orders.updated_at, orders.arranged_at, users.email as user_email
+		FROM orders
+			JOIN users ON users.id = orders.user_id
+		WHERE orders.id = $1`, id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, OrderNotFoundErr
 	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting order with id: %d", id)
+	}
 
-	orderItems := make([]*orderItem.OrderItem, 0)
-	err = r.db.Select(ctx, &orderItems, ` 
-		SELECT 
-		    order_items.quantity, order_items.order_id, order_items.created_at, order_items.updated_at,
-		    p.id as "product.id", p.name as "product.name", p.description as "product.description", p.price as "product.price", p.stock as "product.stock",
-        	p.image as "product.image", p.created_at as "product.created_at", p.updated_at as "product.updated_at",
-        	c.id as "product.category.id", c.name as "product.category.name", c.updated_at as "product.category.updated_at", c.created_at as "product.category.created_at",
-       		c2.id as "product.company.id", c2.name as "product.company.name", c2.updated_at as "product.company.updated_at", c2.created_at as "product.company.created_at"
-		FROM order_items
-			JOIN products p on p.id = order_items.product_id
-			JOIN categories c on p.category_id = c.id
-			JOIN companies c2 on p.company_id = c2.id
-		WHERE order_items.order_id = $1
-			`, o.ID)
+	orderItems, err := r.readOrderItems(ctx, o.ID)
 	o.OrderItems = orderItems
 	o.Count = uint64(len(orderItems))
 
@@ -146,22 +369,227 @@ func (r *OrderRepository) ReadByIdEager(ctx context.Context, id uint64) (*Order,
 		return nil, err
 	}
 
+	o.Taxes, err = r.readTaxes(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Gift, err = r.readGift(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Bundles, err = r.readBundles(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &o, nil
 }
 
+// ReadArranged returns a page of arranged orders matching the filter and the number of all matching orders
+func (r *OrderRepository) ReadArranged(ctx context.Context, filter *Filter) ([]*Order, uint64, error) {
+	where, args := filter.where()
+
+	var count uint64
+	err := r.db.Get(ctx, &count, `
+		SELECT COUNT(*)
+		FROM orders
+			JOIN users ON users.id = orders.user_id
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "error counting orders by filter: %v", filter)
+	}
+
+	orders := make([]*Order, 0)
+	err = r.db.Select(ctx, &orders, fmt.Sprintf(`
+		SELECT orders.id, `+totalColumn+`, orders.discount, orders.promo_code_id, orders.refunded_total, orders.tax_total,
+		       orders.tax_inclusive, orders.currency, orders.exchange_rate,
+		       orders.status, orders.is_arranged, orders.cart_name, orders.user_id,
+		       orders.created_at, orders.updated_at, orders.arranged_at, users.email as user_email,
+		       (SELECT COUNT(*) FROM order_items WHERE order_items.order_id = orders.id) as count
+		FROM orders
+			JOIN users ON users.id = orders.user_id
+		WHERE %s
+		ORDER BY %s
+		LIMIT %d OFFSET %d`, where, filter.orderBy(), filter.Limit, filter.offset()), args...)
+
+	return orders, count, errors.Wrapf(err, "error getting orders by filter: %v", filter)
+}
+
+func (r *OrderRepository) readOrderItems(ctx context.Context, orderID uint64) ([]*orderItem.OrderItem, error) {
+	orderItems := make([]*orderItem.OrderItem, 0)
+	err := r.db.Select(ctx, &orderItems, eagerOrderItemsQuery, orderID)
+	return orderItems, errors.Wrapf(err, "error getting items of order with id: %d", orderID)
+}
+
 func (r *OrderRepository) GetUserIDByNotArrangedOrderID(ctx context.Context, orderID uint64) (uint64, error) {
 	var userID uint64
 
 	err := r.db.Get(ctx, &userID, `
 		SELECT orders.user_id
 		FROM orders
-		WHERE orders.id = $1 AND is_arranged = false
+		WHERE orders.id = $1 AND is_arranged = false AND cart_name IS NULL
 		`, orderID)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return 0, OrderNotFoundErr
 	}
 
-	return userID, nil
+	return userID, errors.Wrapf(err, "error getting owner of cart with id: %d", orderID)
+}
+
+// CreateNamedCart creates a named cart of the user, names of the carts are unique per user
+func (r *OrderRepository) CreateNamedCart(ctx context.Context, userID uint64, name string) (*Order, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, "INSERT INTO orders(status, user_id, cart_name) VALUES ($1, $2, $3) RETURNING id",
+		StatusCreated, userID, name).Scan(&id)
+	if repository.IsUniqueViolation(err) {
+		return nil, CartNameTakenErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating cart %q for user with id: %d", name, userID)
+	}
+
+	return r.ReadById(ctx, id)
+}
+
+// ReadOrCreateNamedCart returns the named cart of the user creating it the first time
+func (r *OrderRepository) ReadOrCreateNamedCart(ctx context.Context, userID uint64, name string) (*Order, error) {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO orders(status, user_id, cart_name) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, cart_name) WHERE is_arranged = FALSE AND cart_name IS NOT NULL DO NOTHING`,
+		StatusCreated, userID, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating cart %q for user with id: %d", name, userID)
+	}
+
+	var o Order
+	err = r.db.Get(ctx, &o, "SELECT "+orderColumns+" FROM orders WHERE user_id = $1 AND is_arranged = false AND cart_name = $2",
+		userID, name)
+	return &o, errors.Wrapf(err, "error getting cart %q of user with id: %d", name, userID)
+}
+
+// ReadNamedCarts returns named carts of the user with their items
+func (r *OrderRepository) ReadNamedCarts(ctx context.Context, userID uint64) ([]*Order, error) {
+	carts := make([]*Order, 0)
+	err := r.db.Select(ctx, &carts, "SELECT "+orderColumns+`
+		FROM orders
+		WHERE user_id = $1 AND is_arranged = false AND cart_name IS NOT NULL
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting named carts of user with id: %d", userID)
+	}
+
+	for _, cart := range carts {
+		cart.OrderItems, err = r.readOrderItems(ctx, cart.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting items of cart with id: %d", cart.ID)
+		}
+		cart.Count = uint64(len(cart.OrderItems))
+	}
+
+	return carts, nil
+}
+
+// ReadNamedCart returns the named cart if it belongs to the user
+func (r *OrderRepository) ReadNamedCart(ctx context.Context, id, userID uint64) (*Order, error) {
+	var o Order
+	err := r.db.Get(ctx, &o, "SELECT "+orderColumns+`
+		FROM orders
+		WHERE id = $1 AND user_id = $2 AND is_arranged = false AND cart_name IS NOT NULL`, id, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, OrderNotFoundErr
+	}
+	return &o, errors.Wrapf(err, "error getting named cart with id: %d", id)
+}
+
+// DeleteNamedCart deletes the named cart of the user with its items
+func (r *OrderRepository) DeleteNamedCart(ctx context.Context, id, userID uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM orders
+		WHERE id = $1 AND user_id = $2 AND is_arranged = false AND cart_name IS NOT NULL`, id, userID)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting named cart with id: %d", id)
+}
+
+// MoveItem moves the product with its quantity and the price the customer has seen from one cart to another.
+// Quantities are summed up if the target cart already has the product
+func (r *OrderRepository) MoveItem(ctx context.Context, fromID, toID, productID uint64) (bool, error) {
+	var count int
+	err := r.db.ExecQueryRow(ctx, `
+		WITH moved AS (
+			DELETE FROM order_items
+			WHERE order_id = $1 AND product_id = $3
+			RETURNING product_id, quantity, seen_price
+		), inserted AS (
+			INSERT INTO order_items(order_id, product_id, quantity, seen_price)
+			SELECT $2, product_id, quantity, seen_price FROM moved
+			ON CONFLICT (order_id, product_id) DO UPDATE
+			SET quantity = order_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+		)
+		SELECT COUNT(*) FROM moved`, fromID, toID, productID).Scan(&count)
+	return count > 0, errors.Wrapf(err, "error moving product %d from cart %d to cart %d", productID, fromID, toID)
+}
+
+// AddBundle adds the bundle to the cart, quantities are summed up if the cart already has the bundle
+func (r *OrderRepository) AddBundle(ctx context.Context, orderID, bundleID, quantity uint64) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO order_bundles(order_id, bundle_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_id, bundle_id) DO UPDATE
+		SET quantity = order_bundles.quantity + EXCLUDED.quantity, updated_at = NOW()`, orderID, bundleID, quantity)
+	return errors.Wrapf(err, "error adding bundle %d to cart with id: %d", bundleID, orderID)
+}
+
+// RemoveBundle removes the bundle from the cart and returns its quantity, its order items are left to the caller
+func (r *OrderRepository) RemoveBundle(ctx context.Context, orderID, bundleID uint64) (uint64, error) {
+	var quantity uint64
+	err := r.db.ExecQueryRow(ctx, "DELETE FROM order_bundles WHERE order_id = $1 AND bundle_id = $2 RETURNING quantity",
+		orderID, bundleID).Scan(&quantity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, BundleNotInCartErr
+	}
+	return quantity, errors.Wrapf(err, "error removing bundle %d from cart with id: %d", bundleID, orderID)
+}
+
+// RemoveBundlesOfProduct removes bundles of the product from the cart once the product is removed from it
+func (r *OrderRepository) RemoveBundlesOfProduct(ctx context.Context, orderID, productID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM order_bundles
+		WHERE order_id = $1
+			AND bundle_id IN (SELECT bundle_id FROM bundle_items WHERE product_id = $2)`, orderID, productID)
+	return errors.Wrapf(err, "error removing bundles of product %d from cart with id: %d", productID, orderID)
+}
+
+// SnapshotOrderBundles fixes current prices of the bundles of the order, bundles removed from the catalog
+// are dropped as they don't discount the order anymore
+func (r *OrderRepository) SnapshotOrderBundles(ctx context.Context, orderID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM order_bundles
+		USING bundles
+		WHERE bundles.id = order_bundles.bundle_id AND order_bundles.order_id = $1
+			AND order_bundles.price IS NULL AND bundles.deleted_at IS NOT NULL`, orderID)
+	if err != nil {
+		return errors.Wrapf(err, "error dropping removed bundles of order with id: %d", orderID)
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE order_bundles
+		SET price = bundles.price, updated_at = NOW()
+		FROM bundles
+		WHERE bundles.id = order_bundles.bundle_id AND order_bundles.order_id = $1 AND order_bundles.price IS NULL`, orderID)
+	return errors.Wrapf(err, "error making snapshot of bundles for order with id: %d", orderID)
+}
+
+func (r *OrderRepository) readBundles(ctx context.Context, orderID uint64) ([]*BundleLine, error) {
+	lines := make([]*BundleLine, 0)
+	err := r.db.Select(ctx, &lines, `
+		SELECT order_bundles.order_id, order_bundles.bundle_id, bundles.name, order_bundles.quantity,
+		       COALESCE(order_bundles.price, bundles.price) as price
+		FROM order_bundles
+			JOIN bundles ON bundles.id = order_bundles.bundle_id
+		WHERE order_bundles.order_id = $1 AND (order_bundles.price IS NOT NULL OR bundles.deleted_at IS NULL)
+		ORDER BY order_bundles.created_at, order_bundles.bundle_id`, orderID)
+	return lines, errors.Wrapf(err, "error getting bundles of order with id: %d", orderID)
 }
 
 func (r *OrderRepository) GetUserEmailByOrderUserID(ctx context.Context, userID uint64) (string, error) {
@@ -178,3 +606,22 @@ func (r *OrderRepository) GetUserEmailByOrderUserID(ctx context.Context, userID
 
 	return email, nil
 }
+
+func (r *OrderRepository) CreateStatusChange(ctx context.Context, change *StatusChange) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO order_status_history(order_id, from_status, to_status, user_id, role_name, comment)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4::BIGINT, 0), $5, $6)`,
+		change.OrderID, change.FromStatus, change.ToStatus, change.UserID, change.Role, change.Comment)
+	return errors.Wrapf(err, "error creating status change: %v", change)
+}
+
+func (r *OrderRepository) ReadStatusHistory(ctx context.Context, orderID uint64) ([]*StatusChange, error) {
+	changes := make([]*StatusChange, 0)
+	err := r.db.Select(ctx, &changes, `
+		SELECT id, order_id, COALESCE(from_status, '') as from_status, to_status, COALESCE(user_id, 0) as user_id,
+		       role_name, comment, created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id`, orderID)
+	return changes, errors.Wrapf(err, "error getting status history of order with id: %d", orderID)
+}
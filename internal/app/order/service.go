@@ -2,10 +2,18 @@ package order
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
 	"github.com/Mickey327/rcsp-backend/internal/app/config"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
 	"github.com/Mickey327/rcsp-backend/internal/app/mail"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
 	"github.com/labstack/echo/v4"
 )
 
@@ -14,18 +22,87 @@ type Repository interface {
 	ReadCurrentUserArrangingOrderLazy(ctx context.Context, userID uint64) (*Order, error)
 	ReadCurrentUserArrangingOrderEager(ctx context.Context, userID uint64) (*Order, error)
 	ReadByIdEager(ctx context.Context, id uint64) (*Order, error)
+	ReadArranged(ctx context.Context, filter *Filter) ([]*Order, uint64, error)
 	Update(ctx context.Context, order *Order) (bool, error)
+	LockById(ctx context.Context, id uint64) (*Order, error)
+	LockOrderProducts(ctx context.Context, orderID uint64) ([]*orderItem.OrderItem, error)
+	DecrementStock(ctx context.Context, orderID uint64) error
+	RestoreStock(ctx context.Context, orderID uint64) error
+	SnapshotOrderItems(ctx context.Context, orderID uint64) error
+	SnapshotOrderBundles(ctx context.Context, orderID uint64) error
+	UpdateDiscount(ctx context.Context, orderID uint64, promoCodeID *uint64, discount uint64) error
+	SaveTaxes(ctx context.Context, order *Order) error
+	UpdateCurrency(ctx context.Context, order *Order) error
+	LockCartItemStates(ctx context.Context, orderID uint64) ([]*CartItemState, error)
+	RepriceItem(ctx context.Context, orderID, productID, quantity, seenPrice uint64) error
+	DeleteItem(ctx context.Context, orderID, productID uint64) error
+	CreateStatusChange(ctx context.Context, change *StatusChange) error
+	ReadStatusHistory(ctx context.Context, orderID uint64) ([]*StatusChange, error)
 	GetUserEmailByOrderUserID(ctx context.Context, userID uint64) (string, error)
+	SaveGift(ctx context.Context, gift *Gift) error
+	ReadDueGifts(ctx context.Context) ([]*Gift, error)
+	ClaimGift(ctx context.Context, orderID uint64) (bool, error)
+	OpenGift(ctx context.Context, token string) (*Gift, error)
+}
+
+// Promotions validates the promo code applied to the order once more at checkout and redeems it.
+// Validation failures are returned as PromoCodeErr
+type Promotions interface {
+	Redeem(ctx context.Context, order *Order) (uint64, error)
+	Refresh(ctx context.Context, cart *Order) error
+}
+
+// Taxes calculates tax lines of the order loaded with its items, taxes are fixed at checkout
+type Taxes interface {
+	Calculate(ctx context.Context, order *Order) ([]*TaxLine, bool, error)
+}
+
+// Currencies quotes the currency the client asked amounts to be shown in
+type Currencies interface {
+	DisplayQuote(ctx context.Context) *currency.Quote
+	Quote(ctx context.Context) (*currency.Quote, error)
+}
+
+// Reservations hold stock for the items of carts, reservations are extended on activity in the cart
+// and released at checkout when the stock is taken
+type Reservations interface {
+	Reserve(ctx context.Context, orderID uint64) error
+	Release(ctx context.Context, orderID uint64) error
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
 }
 
 type OrderService struct {
-	repository Repository
+	repository   Repository
+	transactor   Transactor
+	promotions   Promotions
+	taxes        Taxes
+	currencies   Currencies
+	reservations Reservations
+	sideEffects  map[string]func(ctx context.Context, order *Order, change *StatusChange)
 }
 
-func NewService(repository Repository) *OrderService {
-	return &OrderService{
-		repository: repository,
+func NewService(repository Repository, transactor Transactor, promotions Promotions, taxes Taxes, currencies Currencies,
+	reservations Reservations) *OrderService {
+	s := &OrderService{
+		repository:   repository,
+		transactor:   transactor,
+		promotions:   promotions,
+		taxes:        taxes,
+		currencies:   currencies,
+		reservations: reservations,
 	}
+	s.sideEffects = map[string]func(ctx context.Context, order *Order, change *StatusChange){
+		StatusAwaitingPayment: s.sendAwaitingPaymentMail,
+		StatusPaid:            s.sendStatusMail,
+		StatusDelivered:       s.sendStatusMail,
+		StatusCancelled:       s.sendCancelledMail,
+		StatusRefunded:        s.sendStatusMail,
+	}
+	return s
 }
 
 func (s *OrderService) Create(c echo.Context, userID uint64) (*DTO, error) {
@@ -48,37 +125,473 @@ func (s *OrderService) ReadByIdEager(c echo.Context, id uint64) (*DTO, error) {
 	return order.ToDTO(), nil
 }
 
-func (s *OrderService) Update(c echo.Context, dto *DTO) (bool, error) {
-	isUpdated, err := s.repository.Update(c.Request().Context(), dto.ToOrder())
+// ReadArranged returns a page of arranged orders and the number of all orders matching the filter
+func (s *OrderService) ReadArranged(c echo.Context, filter *Filter) ([]*DTO, uint64, error) {
+	orders, count, err := s.repository.ReadArranged(c.Request().Context(), filter)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ToDTOs(orders), count, nil
+}
+
+func (s *OrderService) ReadStatusHistory(c echo.Context, orderID uint64) ([]*StatusChangeDTO, error) {
+	changes, err := s.repository.ReadStatusHistory(c.Request().Context(), orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return StatusChangesToDTOs(changes), nil
+}
+
+// Update changes the status of the order, the checkout can buy the order as a gift for another person
+func (s *OrderService) Update(c echo.Context, dto *StatusUpdateDTO, actor *auth.UserData) (*DTO, error) {
+	if dto.Gift == nil {
+		return s.ChangeStatus(c.Request().Context(), dto.ID, dto.Status, actor, dto.Comment)
+	}
+
+	if dto.Status != StatusAwaitingPayment {
+		return nil, GiftNotAllowedErr
+	}
+
+	gift := dto.Gift.ToGift()
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	gift.Token = hex.EncodeToString(token)
+
+	return s.changeStatus(c.Request().Context(), dto.ID, dto.Status, actor, dto.Comment, gift)
+}
+
+// Cancel cancels the arranged order and returns its items to the stock. Users can cancel only their own
+// orders which are not paid yet, admins can cancel any arranged order but have to give a reason
+func (s *OrderService) Cancel(c echo.Context, id uint64, dto *CancelDTO, actor *auth.UserData) (*DTO, error) {
+	if actor.Role == "admin" && dto.Reason == "" {
+		return nil, CancelReasonErr
+	}
+
+	return s.ChangeStatus(c.Request().Context(), id, StatusCancelled, actor, dto.Reason)
+}
+
+// BulkUpdate moves every order to the status separately, so one rejected transition doesn't affect others
+func (s *OrderService) BulkUpdate(c echo.Context, dto *BulkStatusUpdateDTO, actor *auth.UserData) []*BulkStatusResultDTO {
+	results := make([]*BulkStatusResultDTO, 0, len(dto.IDs))
+
+	for _, id := range dto.IDs {
+		result := &BulkStatusResultDTO{ID: id}
+
+		order, err := s.ChangeStatus(c.Request().Context(), id, dto.Status, actor, dto.Comment)
+		if err != nil {
+			result.Error = "ошибка обновления заказа"
+			var stockErr *InsufficientStockErr
+			if errors.Is(err, OrderNotFoundErr) || errors.Is(err, TransitionErr) || errors.As(err, &stockErr) {
+				result.Error = err.Error()
+			}
+		} else {
+			result.Status = order.Status
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// ChangeStatus moves the order to the status on behalf of the actor if the order lifecycle allows it.
+// Every transition is recorded in the order status history and its side effects run after the commit
+// of the outermost transaction, so nothing is sent when the caller's transaction rolls back.
+// Moving a created order to awaiting payment is the checkout: stock is reserved for every order item,
+// item prices are fixed and the next arranging order is created for the user. Orders cancelled before delivery
+// are restocked.
+func (s *OrderService) ChangeStatus(ctx context.Context, id uint64, status string, actor *auth.UserData, comment string) (*DTO, error) {
+	return s.changeStatus(ctx, id, status, actor, comment, nil)
+}
+
+// changeStatus is ChangeStatus which saves the gift with the checkout if the order is bought as a gift
+func (s *OrderService) changeStatus(ctx context.Context, id uint64, status string, actor *auth.UserData, comment string, gift *Gift) (*DTO, error) {
+	var order *Order
+	var changedCart *CartCheckDTO
+	change := &StatusChange{
+		OrderID:  id,
+		ToStatus: status,
+		UserID:   actor.ID,
+		Role:     actor.Role,
+		Comment:  comment,
+	}
+
+	err := s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = s.repository.LockById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if actor.Role == "user" && order.UserID != actor.ID {
+			return ForeignOrderErr
+		}
+
+		if order.CartName != nil {
+			return NamedCartErr
+		}
+
+		if !CanTransition(order.Status, status, actor.Role) {
+			return TransitionErr
+		}
+		change.FromStatus = order.Status
+
+		if order.Status == StatusCreated {
+			checks, err := s.checkCart(ctx, order)
+			if err != nil {
+				return err
+			}
+			// corrections of the cart are committed, so the customer can check out once they have seen them
+			if !isCartValid(checks) {
+				changedCart = ItemChecksToDTO(checks, s.currencies.DisplayQuote(ctx))
+				return nil
+			}
+
+			if err = s.checkout(ctx, order); err != nil {
+				return err
+			}
+
+			if gift != nil {
+				gift.OrderID = order.ID
+				if err = s.repository.SaveGift(ctx, gift); err != nil {
+					return err
+				}
+				order.Gift = gift
+			}
+		}
+
+		// delivered goods have left the warehouse, so only orders cancelled before delivery are restocked
+		if status == StatusCancelled && (order.Status == StatusAwaitingPayment || order.Status == StatusPaid) {
+			if err = s.repository.RestoreStock(ctx, order.ID); err != nil {
+				return err
+			}
+		}
+
+		order.Status = status
+		order.IsArranged = true
+		if _, err = s.repository.Update(ctx, order); err != nil {
+			return err
+		}
+
+		if err = s.repository.CreateStatusChange(ctx, change); err != nil {
+			return err
+		}
+
+		if sideEffect, ok := s.sideEffects[status]; ok {
+			s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+				sideEffect(ctx, order, change)
+			})
+		}
+		return nil
+	})
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if changedCart != nil {
+		return nil, &CartChangedErr{Check: changedCart}
 	}
-	if dto.Status == "Ожидает оплаты" && dto.IsArranged == true {
-		email, err := s.repository.GetUserEmailByOrderUserID(c.Request().Context(), dto.UserID)
+
+	return order.ToDTO(), nil
+}
+
+// ValidateCart checks the user's cart against its products and corrects it, see checkCart
+func (s *OrderService) ValidateCart(c echo.Context, userID uint64) (*CartCheckDTO, error) {
+	var checks []*ItemCheck
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		cart, err := s.repository.ReadCurrentUserArrangingOrderLazy(ctx, userID)
 		if err != nil {
-			return false, nil
+			return err
+		}
+
+		checks, err = s.checkCart(ctx, cart)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ItemChecksToDTO(checks, s.currencies.DisplayQuote(c.Request().Context())), nil
+}
+
+// checkCart brings the cart in line with its products: items of removed products are deleted, quantities
+// are reduced to the stock and current prices are remembered as seen by the customer. Items out of stock
+// are kept, so the customer decides what to do with them. Reservations of the cart are extended and
+// the promo code discount is recalculated afterwards
+func (s *OrderService) checkCart(ctx context.Context, cart *Order) ([]*ItemCheck, error) {
+	states, err := s.repository.LockCartItemStates(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]*ItemCheck, 0, len(states))
+	for _, state := range states {
+		check := checkItem(state)
+		checks = append(checks, check)
+
+		switch check.Status {
+		case ItemUnchanged, ItemOutOfStock:
+			if state.SeenPrice == nil || *state.SeenPrice != state.Price {
+				err = s.repository.RepriceItem(ctx, cart.ID, state.ProductID, state.Quantity, state.Price)
+			}
+		case ItemRemoved:
+			err = s.repository.DeleteItem(ctx, cart.ID, state.ProductID)
+		default:
+			err = s.repository.RepriceItem(ctx, cart.ID, state.ProductID, check.Quantity, check.Price)
 		}
-		cfg := config.GetConfig()
-		message := fmt.Sprintf("Здравствуйте, спасибо что оформили у нас заказ! Для оплаты переведите деньги на карту "+
-			"1234 5678 9012 3456 или по номеру телефона +7(123)456-78-90, указав в сообщении с переводом вашу почту на сайте "+
-			"Статус заказа и его содержание можете отслеживать по ссылке: %s/checkout?orderID=%d\n", cfg.OuterClientAddress, dto.ID)
-		order, err := s.repository.ReadByIdEager(c.Request().Context(), dto.ID)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
+	}
+
+	if err = s.reservations.Reserve(ctx, cart.ID); err != nil {
+		return nil, err
+	}
+
+	if cart.PromoCodeID == nil || isCartValid(checks) {
+		return checks, nil
+	}
+
+	eager, err := s.repository.ReadByIdEager(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	return checks, s.promotions.Refresh(ctx, eager)
+}
+
+func (s *OrderService) checkout(ctx context.Context, order *Order) error {
+	// the order is locked in the currency the customer has chosen, it never falls back to the base currency
+	q, err := s.currencies.Quote(ctx)
+	if err != nil {
+		return err
+	}
+
+	items, err := s.repository.LockOrderProducts(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return OrderEmptyErr
+	}
+
+	if shortages := findStockShortages(items); len(shortages) > 0 {
+		return &InsufficientStockErr{Items: shortages}
+	}
+
+	if err = s.repository.DecrementStock(ctx, order.ID); err != nil {
+		return err
+	}
+
+	if err = s.reservations.Release(ctx, order.ID); err != nil {
+		return err
+	}
+
+	if err = s.repository.SnapshotOrderItems(ctx, order.ID); err != nil {
+		return err
+	}
+
+	if err = s.repository.SnapshotOrderBundles(ctx, order.ID); err != nil {
+		return err
+	}
+
+	if order.PromoCodeID != nil {
+		if order.Discount, err = s.promotions.Redeem(ctx, order); err != nil {
+			return err
+		}
+		if err = s.repository.UpdateDiscount(ctx, order.ID, order.PromoCodeID, order.Discount); err != nil {
+			return err
+		}
+	}
+
+	if err = s.fixTaxes(ctx, order); err != nil {
+		return err
+	}
+
+	order.SetQuote(q)
+	if err = s.repository.UpdateCurrency(ctx, order); err != nil {
+		return err
+	}
+
+	_, err = s.repository.Create(ctx, order.UserID)
+	return err
+}
+
+// fixTaxes calculates taxes of the order with its final prices and discount and stores them
+func (s *OrderService) fixTaxes(ctx context.Context, order *Order) error {
+	arranged, err := s.repository.ReadByIdEager(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	order.Taxes, order.TaxInclusive, err = s.taxes.Calculate(ctx, arranged)
+	if err != nil {
+		return err
+	}
+
+	order.TaxTotal = 0
+	for _, line := range order.Taxes {
+		order.TaxTotal += line.Amount
+	}
+
+	return s.repository.SaveTaxes(ctx, order)
+}
+
+func (s *OrderService) sendAwaitingPaymentMail(ctx context.Context, order *Order, _ *StatusChange) {
+	cfg := config.GetConfig()
+	message := fmt.Sprintf("Здравствуйте, спасибо что оформили у нас заказ! Оплатить заказ, отслеживать его статус "+
+		"и содержание можете по ссылке: %s/checkout?orderID=%d\n", cfg.OuterClientAddress, order.ID)
+	order, err := s.repository.ReadByIdEager(ctx, order.ID)
+	if err != nil {
+		log.Println("error reading arranged order:", err)
+		return
+	}
+	for _, item := range order.OrderItems {
+		message += fmt.Sprintf("%s: %s/шт %d шт, общая цена позиции: %s\n", item.ProductName, order.Format(item.Price),
+			item.Quantity, order.Format(item.Price*uint64(item.Quantity)))
+	}
+	if order.Discount > 0 {
+		message += fmt.Sprintf("Скидка по промокоду: %s\n", order.Format(order.Discount))
+	}
+	for _, line := range order.Taxes {
+		if order.TaxInclusive {
+			message += fmt.Sprintf("%s %s (включен в цену): %s\n", line.Name, formatRate(line.Rate), order.Format(line.Amount))
+		} else {
+			message += fmt.Sprintf("%s %s: %s\n", line.Name, formatRate(line.Rate), order.Format(line.Amount))
+		}
+	}
+	message += fmt.Sprintf("Номер заказа: %d, общая цена заказа: %s, статус: %s", order.ID, order.Format(order.Payable()), order.Status)
+	s.NotifyCustomer(ctx, order.UserID, "Заказ был успешно взят в обработку", message)
+}
+
+func (s *OrderService) sendStatusMail(ctx context.Context, order *Order, change *StatusChange) {
+	cfg := config.GetConfig()
+	message := fmt.Sprintf("Здравствуйте, статус вашего заказа №%d изменился: %s.\n", order.ID, order.Status)
+	if change.Comment != "" {
+		message += fmt.Sprintf("Комментарий: %s\n", change.Comment)
+	}
+	message += fmt.Sprintf("Заказ можете отслеживать по ссылке: %s/checkout?orderID=%d", cfg.OuterClientAddress, order.ID)
+	s.NotifyCustomer(ctx, order.UserID, fmt.Sprintf("Заказ №%d: %s", order.ID, order.Status), message)
+}
+
+func (s *OrderService) sendCancelledMail(ctx context.Context, order *Order, change *StatusChange) {
+	cfg := config.GetConfig()
+	message := fmt.Sprintf("Здравствуйте, ваш заказ №%d на сумму %s был отменен.\n", order.ID, order.Format(order.Payable()))
+	if change.Comment != "" {
+		message += fmt.Sprintf("Причина отмены: %s\n", change.Comment)
+	}
+	message += fmt.Sprintf("Подробности заказа можете посмотреть по ссылке: %s/checkout?orderID=%d", cfg.OuterClientAddress, order.ID)
+	s.NotifyCustomer(ctx, order.UserID, fmt.Sprintf("Заказ №%d отменен", order.ID), message)
+}
+
+// SendGifts emails gifts to their recipients once the orders are paid and the delivery dates have come,
+// the buyer is told that the gift has been sent. Gifts whose orders can't be read are left for the next run
+func (s *OrderService) SendGifts(ctx context.Context) error {
+	gifts, err := s.repository.ReadDueGifts(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.GetConfig()
+	sent := 0
+	for _, gift := range gifts {
+		order, err := s.repository.ReadByIdEager(ctx, gift.OrderID)
+		if err != nil {
+			log.Println("error getting gift order:", err)
+			continue
+		}
+
+		// the gift is claimed first, so it's never sent twice even if the run fails after sending
+		isClaimed, err := s.repository.ClaimGift(ctx, gift.OrderID)
+		if err != nil {
+			return err
+		}
+		if !isClaimed {
+			continue
+		}
+
+		message := fmt.Sprintf("Здравствуйте! %s дарит вам подарок:\n", order.UserEmail)
 		for _, item := range order.OrderItems {
-			message += fmt.Sprintf("%s: %d ₽/шт %d шт, общая цена позиции: %d ₽\n", item.Product.Name, item.Product.Price, item.Quantity, int(item.Product.Price)*item.Quantity)
+			message += fmt.Sprintf("%s — %d шт.\n", item.ProductName, item.Quantity)
 		}
-		message += fmt.Sprintf("Номер заказа: %d, общая цена заказа: %d ₽, статус: %s", order.ID, order.Total, order.Status)
-		m := mail.New(cfg.Email, email, "Заказ был успешно взят в обработку", message)
+		if gift.Message != "" {
+			message += fmt.Sprintf("Сообщение: %s\n", gift.Message)
+		}
+		message += fmt.Sprintf("Открыть подарок можете по ссылке: %s/api/gift/%s", cfg.OuterApiAddress, gift.Token)
+		m := mail.New(cfg.Email, gift.RecipientEmail, "Вам подарок", message)
 		m.SendMail()
-		_, err = s.repository.Create(c.Request().Context(), dto.UserID)
-		if err != nil {
-			return false, err
+		sent++
+
+		s.NotifyCustomer(ctx, order.UserID, fmt.Sprintf("Подарок по заказу №%d отправлен", order.ID),
+			fmt.Sprintf("Здравствуйте, подарок по заказу №%d отправлен на %s.", order.ID, gift.RecipientEmail))
+	}
+
+	if sent > 0 {
+		log.Printf("sent %d gifts", sent)
+	}
+	return nil
+}
+
+// OpenGift shows the gift to its recipient and marks it as opened for the buyer
+func (s *OrderService) OpenGift(c echo.Context, token string) (*GiftViewDTO, error) {
+	gift, err := s.repository.OpenGift(c.Request().Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.repository.ReadByIdEager(c.Request().Context(), gift.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order.ToGiftView(), nil
+}
+
+// NotifyCustomer sends an email to the user, failures are only logged
+func (s *OrderService) NotifyCustomer(ctx context.Context, userID uint64, subject, message string) {
+	email, err := s.repository.GetUserEmailByOrderUserID(ctx, userID)
+	if err != nil {
+		log.Println("error getting email of order owner:", err)
+		return
+	}
+	m := mail.New(config.GetConfig().Email, email, subject, message)
+	m.SendMail()
+}
+
+// formatRate formats the tax rate given in hundredths of a percent, e.g. 20% or 6.5%
+func formatRate(rate uint64) string {
+	if rate%100 == 0 {
+		return fmt.Sprintf("%d%%", rate/100)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%02d", rate/100, rate%100), "0") + "%"
+}
+
+func findStockShortages(items []*orderItem.OrderItem) []*StockShortage {
+	shortages := make([]*StockShortage, 0)
+
+	for _, item := range items {
+		requested := uint64(item.Quantity)
+		available := item.Product.Available
+		if item.Product.DeletedAt != nil {
+			available = 0
+		}
+
+		if requested > available {
+			shortages = append(shortages, &StockShortage{
+				ProductID: item.Product.ID,
+				Name:      item.Product.Name,
+				Requested: requested,
+				Available: available,
+			})
 		}
 	}
 
-	return isUpdated, nil
+	return shortages
 }
 
 func (s *OrderService) ReadCurrentUserArrangingOrderLazy(c echo.Context, userID uint64) (*DTO, error) {
@@ -87,10 +600,13 @@ func (s *OrderService) ReadCurrentUserArrangingOrderLazy(c echo.Context, userID
 	if err != nil {
 		return nil, err
 	}
+	order.SetQuote(s.currencies.DisplayQuote(c.Request().Context()))
 
 	return order.ToDTO(), nil
 }
 
+// ReadCurrentUserArrangingOrderEager returns the cart with the taxes it would have if it was arranged now,
+// viewing the cart extends reservations of its items
 func (s *OrderService) ReadCurrentUserArrangingOrderEager(c echo.Context, userID uint64) (*DTO, error) {
 	order, err := s.repository.ReadCurrentUserArrangingOrderEager(c.Request().Context(), userID)
 
@@ -98,5 +614,18 @@ func (s *OrderService) ReadCurrentUserArrangingOrderEager(c echo.Context, userID
 		return nil, err
 	}
 
+	if err = s.reservations.Reserve(c.Request().Context(), order.ID); err != nil {
+		return nil, err
+	}
+
+	order.Taxes, order.TaxInclusive, err = s.taxes.Calculate(c.Request().Context(), order)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range order.Taxes {
+		order.TaxTotal += line.Amount
+	}
+	order.SetQuote(s.currencies.DisplayQuote(c.Request().Context()))
+
 	return order.ToDTO(), nil
 }
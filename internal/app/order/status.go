@@ -0,0 +1,51 @@
+package order
+
+const (
+	StatusCreated         = "Создан"
+	StatusAwaitingPayment = "Ожидает оплаты"
+	StatusPaid            = "Оплачен"
+	StatusDelivered       = "Доставлен"
+	StatusCancelled       = "Отменен"
+	StatusRefunded        = "Возвращен"
+)
+
+// SystemRole is used for transitions made by the store itself rather than by a user or an admin
+const SystemRole = "system"
+
+// transitions lists for every status the statuses order can be moved to and the roles allowed to do so.
+// Paid orders aren't cancelled, they are refunded only by approved refunds, which return the money and the items
+var transitions = map[string]map[string][]string{
+	StatusCreated: {
+		StatusAwaitingPayment: {"user"},
+	},
+	StatusAwaitingPayment: {
+		StatusPaid:      {"admin", SystemRole},
+		StatusCancelled: {"user", "admin", SystemRole},
+	},
+	StatusPaid: {
+		StatusDelivered: {"admin", SystemRole},
+		StatusRefunded:  {SystemRole},
+	},
+	StatusDelivered: {
+		StatusRefunded: {SystemRole},
+	},
+}
+
+// CanTransition reports whether the role is allowed to move an order from one status to another
+func CanTransition(from, to, role string) bool {
+	for _, allowed := range transitions[from][to] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKnownStatus reports whether the status is a part of the order lifecycle
+func IsKnownStatus(status string) bool {
+	switch status {
+	case StatusCreated, StatusAwaitingPayment, StatusPaid, StatusDelivered, StatusCancelled, StatusRefunded:
+		return true
+	}
+	return false
+}
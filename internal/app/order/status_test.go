@@ -0,0 +1,64 @@
+package order
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		role string
+		want bool
+	}{
+		{"user checks out", StatusCreated, StatusAwaitingPayment, "user", true},
+		{"admin can't check out for user", StatusCreated, StatusAwaitingPayment, "admin", false},
+		{"created order can't be paid", StatusCreated, StatusPaid, SystemRole, false},
+		{"system marks paid", StatusAwaitingPayment, StatusPaid, SystemRole, true},
+		{"admin marks paid", StatusAwaitingPayment, StatusPaid, "admin", true},
+		{"user can't mark paid", StatusAwaitingPayment, StatusPaid, "user", false},
+		{"user cancels unpaid", StatusAwaitingPayment, StatusCancelled, "user", true},
+		{"admin cancels unpaid", StatusAwaitingPayment, StatusCancelled, "admin", true},
+		{"admin delivers", StatusPaid, StatusDelivered, "admin", true},
+		{"user can't deliver", StatusPaid, StatusDelivered, "user", false},
+		{"paid order isn't cancelled", StatusPaid, StatusCancelled, "admin", false},
+		{"delivered order isn't cancelled", StatusDelivered, StatusCancelled, "admin", false},
+		{"system refunds paid", StatusPaid, StatusRefunded, SystemRole, true},
+		{"system refunds delivered", StatusDelivered, StatusRefunded, SystemRole, true},
+		{"admin can't refund directly", StatusPaid, StatusRefunded, "admin", false},
+		{"admin can't refund delivered directly", StatusDelivered, StatusRefunded, "admin", false},
+		{"cancelled is final", StatusCancelled, StatusAwaitingPayment, "admin", false},
+		{"refunded is final", StatusRefunded, StatusPaid, SystemRole, false},
+		{"unknown status", "Неизвестен", StatusPaid, SystemRole, false},
+		{"same status", StatusPaid, StatusPaid, "admin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to, tt.role); got != tt.want {
+				t.Errorf("CanTransition(%q, %q, %q) = %v, want %v", tt.from, tt.to, tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsKnownStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{StatusCreated, true},
+		{StatusAwaitingPayment, true},
+		{StatusPaid, true},
+		{StatusDelivered, true},
+		{StatusCancelled, true},
+		{StatusRefunded, true},
+		{"", false},
+		{"Paid", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsKnownStatus(tt.status); got != tt.want {
+			t.Errorf("IsKnownStatus(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
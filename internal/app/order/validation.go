@@ -0,0 +1,106 @@
+package order
+
+import "github.com/Mickey327/rcsp-backend/internal/app/currency"
+
+const (
+	ItemUnchanged       = "Без изменений"
+	ItemOutOfStock      = "Нет в наличии"
+	ItemQuantityReduced = "Количество уменьшено"
+	ItemPriceChanged    = "Цена изменилась"
+	ItemRemoved         = "Товар удален"
+)
+
+// CartItemState is a cart item with the current state of its product
+type CartItemState struct {
+	ProductID uint64  `db:"product_id"`
+	Name      string  `db:"name"`
+	Quantity  uint64  `db:"quantity"`
+	SeenPrice *uint64 `db:"seen_price"`
+	Price     uint64  `db:"price"`
+	Stock     uint64  `db:"stock"`
+	Available bool    `db:"available"`
+}
+
+// ItemCheck tells what happened to the cart item since the customer last saw it
+type ItemCheck struct {
+	ProductID        uint64
+	Name             string
+	Status           string
+	Quantity         uint64
+	PreviousQuantity uint64
+	Price            uint64
+	PreviousPrice    uint64
+	PriceChanged     bool
+}
+
+// checkItem compares the cart item with its product. A removed product hides every other change,
+// then go a missing stock, a stock lower than the quantity and a changed price. A changed price is also
+// flagged when the status reports the stock, so the customer sees it before the new price is remembered
+func checkItem(state *CartItemState) *ItemCheck {
+	check := &ItemCheck{
+		ProductID:        state.ProductID,
+		Name:             state.Name,
+		Status:           ItemUnchanged,
+		Quantity:         state.Quantity,
+		PreviousQuantity: state.Quantity,
+		Price:            state.Price,
+		PreviousPrice:    state.Price,
+	}
+	if state.SeenPrice != nil {
+		check.PreviousPrice = *state.SeenPrice
+	}
+
+	check.PriceChanged = state.Available && check.PreviousPrice != check.Price
+
+	switch {
+	case !state.Available:
+		check.Status = ItemRemoved
+		check.Quantity = 0
+	case state.Stock == 0:
+		check.Status = ItemOutOfStock
+	case state.Stock < state.Quantity:
+		check.Status = ItemQuantityReduced
+		check.Quantity = state.Stock
+	case check.PriceChanged:
+		check.Status = ItemPriceChanged
+	}
+
+	return check
+}
+
+func (c *ItemCheck) ToDTO(q *currency.Quote) *ItemCheckDTO {
+	return &ItemCheckDTO{
+		ProductID:        c.ProductID,
+		Name:             c.Name,
+		Status:           c.Status,
+		Quantity:         c.Quantity,
+		PreviousQuantity: c.PreviousQuantity,
+		Price:            q.Convert(c.Price),
+		PreviousPrice:    q.Convert(c.PreviousPrice),
+		PriceChanged:     c.PriceChanged,
+	}
+}
+
+// ItemChecksToDTO reports the checks of the cart items in the quoted currency
+func ItemChecksToDTO(checks []*ItemCheck, q *currency.Quote) *CartCheckDTO {
+	cartCheckDTO := &CartCheckDTO{
+		Valid:    isCartValid(checks),
+		Currency: q.Currency,
+		Items:    make([]*ItemCheckDTO, 0, len(checks)),
+	}
+
+	for _, check := range checks {
+		cartCheckDTO.Items = append(cartCheckDTO.Items, check.ToDTO(q))
+	}
+
+	return cartCheckDTO
+}
+
+func isCartValid(checks []*ItemCheck) bool {
+	for _, check := range checks {
+		if check.Status != ItemUnchanged {
+			return false
+		}
+	}
+	return true
+}
@@ -1,17 +1,36 @@
 package orderItem
 
-import "github.com/Mickey327/rcsp-backend/internal/app/product"
+import (
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+)
 
 type DTO struct {
 	OrderID  uint64       `json:"order_id,omitempty"`
 	Product  *product.DTO `json:"product,omitempty"`
 	Quantity int          `json:"quantity,omitempty"`
+	Price    uint64       `json:"price,omitempty"`
+	Name     string       `json:"name,omitempty"`
+	Image    string       `json:"image,omitempty"`
+	Refunded int          `json:"refunded,omitempty"`
+}
+
+// Convert shows the price of the order item and of its product in the quoted currency
+func (d *DTO) Convert(q *currency.Quote) {
+	d.Price = q.Convert(d.Price)
+	if d.Product != nil {
+		d.Product.Convert(q)
+	}
 }
 
 func (d *DTO) ToOrderItem() *OrderItem {
 	orderItem := &OrderItem{
-		OrderID:  d.OrderID,
-		Quantity: d.Quantity,
+		OrderID:      d.OrderID,
+		Quantity:     d.Quantity,
+		Price:        d.Price,
+		ProductName:  d.Name,
+		ProductImage: d.Image,
+		Refunded:     d.Refunded,
 	}
 	if d.Product != nil {
 		orderItem.Product = d.Product.ToProduct()
@@ -7,17 +7,25 @@ import (
 )
 
 type OrderItem struct {
-	OrderID   uint64           `db:"order_id"`
-	Quantity  int              `db:"quantity"`
-	CreatedAt time.Time        `db:"created_at"`
-	UpdatedAt time.Time        `db:"updated_at"`
-	Product   *product.Product `scan:"notate"`
+	OrderID      uint64           `db:"order_id"`
+	Quantity     int              `db:"quantity"`
+	Price        uint64           `db:"price"`
+	ProductName  string           `db:"product_name"`
+	ProductImage string           `db:"product_image"`
+	Refunded     int              `db:"refunded_quantity"`
+	CreatedAt    time.Time        `db:"created_at"`
+	UpdatedAt    time.Time        `db:"updated_at"`
+	Product      *product.Product `scan:"notate"`
 }
 
 func (o *OrderItem) ToDTO() *DTO {
 	orderItemDTO := &DTO{
 		OrderID:  o.OrderID,
 		Quantity: o.Quantity,
+		Price:    o.Price,
+		Name:     o.ProductName,
+		Image:    o.ProductImage,
+		Refunded: o.Refunded,
 	}
 	if o.Product != nil {
 		orderItemDTO.Product = o.Product.ToDTO()
@@ -29,14 +29,24 @@ func NewRepository(db DB) *OrderItemRepository {
 }
 
 func (r *OrderItemRepository) Create(ctx context.Context, orderItem *OrderItem) (bool, error) {
-	result, err := r.db.Exec(ctx, `INSERT INTO order_items(quantity, order_id, product_id) VALUES ($1, $2, $3)`, orderItem.Quantity, orderItem.OrderID, orderItem.Product.ID)
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO order_items(quantity, order_id, product_id, seen_price)
+		SELECT $1, $2, products.id, effective_price(products.id, NOW())
+		FROM products
+			JOIN categories ON categories.id = products.category_id
+			JOIN companies ON companies.id = products.company_id
+		WHERE products.id = $3
+			AND products.deleted_at IS NULL AND categories.deleted_at IS NULL AND companies.deleted_at IS NULL`,
+		orderItem.Quantity, orderItem.OrderID, orderItem.Product.ID)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error creating order item: %v", orderItem)
 }
 
 func (r *OrderItemRepository) Update(ctx context.Context, orderItem *OrderItem) (bool, error) {
 	orderItem.UpdatedAt = time.Now().UTC()
 	result, err := r.db.Exec(ctx,
-		"UPDATE order_items SET quantity = quantity + $1, updated_at = $2 WHERE order_id = $3 AND product_id = $4",
+		`UPDATE order_items
+		SET quantity = quantity + $1, seen_price = effective_price(product_id, NOW()), updated_at = $2
+		WHERE order_id = $3 AND product_id = $4`,
 		orderItem.Quantity, orderItem.UpdatedAt, orderItem.OrderID, orderItem.Product.ID)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating order item: %v", orderItem)
 }
@@ -57,5 +67,5 @@ func (r *OrderItemRepository) ReadByOrderAndProductID(ctx context.Context, order
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, OrderItemNotFound
 	}
-	return &orderItem, nil
+	return &orderItem, errors.Wrapf(err, "error getting product %d of order with id: %d", productID, orderID)
 }
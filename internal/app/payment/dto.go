@@ -0,0 +1,14 @@
+package payment
+
+import "time"
+
+type DTO struct {
+	ID         uint64    `json:"id"`
+	OrderID    uint64    `json:"order_id"`
+	Status     string    `json:"status"`
+	Amount     uint64    `json:"amount"`
+	Currency   string    `json:"currency"`
+	Refunded   uint64    `json:"refunded,omitempty"`
+	PaymentURL string    `json:"payment_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package payment
+
+import "errors"
+
+var (
+	PaymentNotFoundErr      = errors.New("платеж не найден")
+	PaymentNotAllowedErr    = errors.New("заказ не ожидает оплаты")
+	SignatureErr            = errors.New("неверная подпись уведомления о платеже")
+	WebhookPayloadErr       = errors.New("уведомление о платеже представлено в неверном формате")
+	AmountMismatchErr       = errors.New("сумма платежа не совпадает с суммой заказа")
+	RefundExceedsPaymentErr = errors.New("сумма возврата превышает сумму платежа")
+)
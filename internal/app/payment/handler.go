@@ -0,0 +1,158 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/labstack/echo/v4"
+)
+
+// SignatureHeader carries the signature of the webhook payload
+const SignatureHeader = "X-Payment-Signature"
+
+type Service interface {
+	Create(c echo.Context, orderID uint64, actor *auth.UserData) (*DTO, error)
+	ReadByOrderID(c echo.Context, orderID uint64, actor *auth.UserData) ([]*DTO, error)
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+
+	if err != nil {
+		return err
+	}
+
+	orderID, err := parseOrderID(c)
+	if err != nil {
+		return err
+	}
+
+	paymentDTO, err := h.service.Create(c, orderID, userData)
+	if err != nil {
+		return paymentError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"payment": paymentDTO,
+	})
+}
+
+func (h *Handler) ReadByOrderID(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user", "admin")
+
+	if err != nil {
+		return err
+	}
+
+	orderID, err := parseOrderID(c)
+	if err != nil {
+		return err
+	}
+
+	paymentDTOs, err := h.service.ReadByOrderID(c, orderID, userData)
+	if err != nil {
+		return paymentError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":     http.StatusOK,
+		"payments": paymentDTOs,
+	})
+}
+
+// Webhook receives payment events from the provider. Errors other than a bad request make
+// the provider deliver the event again later
+func (h *Handler) Webhook(c echo.Context) error {
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, WebhookPayloadErr.Error())
+	}
+
+	if err = h.service.HandleWebhook(c.Request().Context(), payload, c.Request().Header.Get(SignatureHeader)); err != nil {
+		return paymentError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+	})
+}
+
+// MockHandler imitates the payment page of the mock provider
+type MockHandler struct {
+	provider *MockProvider
+	service  Service
+}
+
+func NewMockHandler(provider *MockProvider, service Service) *MockHandler {
+	return &MockHandler{
+		provider: provider,
+		service:  service,
+	}
+}
+
+// Pay sends the webhook event of the successful payment of the intent, or of the failed one with ?fail=true
+func (h *MockHandler) Pay(c echo.Context) error {
+	eventType := EventSucceeded
+	if c.QueryParam("fail") == "true" {
+		eventType = EventFailed
+	}
+
+	payload, signature, err := h.provider.Event(eventType, c.Param("intentID"))
+	if err != nil {
+		return paymentError(err)
+	}
+
+	if err = h.service.HandleWebhook(c.Request().Context(), payload, signature); err != nil {
+		return paymentError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "платеж обработан",
+	})
+}
+
+func parseOrderID(c echo.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id заказа")
+	}
+	if id <= 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "id заказа должно быть положительным")
+	}
+	return id, nil
+}
+
+// paymentError converts errors of payment service to http responses
+func paymentError(err error) error {
+	switch {
+	case errors.Is(err, PaymentNotFoundErr), errors.Is(err, order.OrderNotFoundErr):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, order.ForeignOrderErr):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, SignatureErr):
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	case errors.Is(err, WebhookPayloadErr), errors.Is(err, AmountMismatchErr):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, PaymentNotAllowedErr), errors.Is(err, RefundExceedsPaymentErr):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, "ошибка обработки платежа")
+}
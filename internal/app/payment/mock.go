@@ -0,0 +1,118 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+const MockProviderName = "mock"
+
+// MockProvider is a local provider for development and tests. Nothing is charged, the payment url leads
+// to the mock payment page of the api which sends a signed webhook event as if the customer paid.
+// Intents are kept in memory, so they can't be paid after restart
+type MockProvider struct {
+	secret  []byte
+	baseURL string
+
+	mu      sync.Mutex
+	intents map[string]uint64
+}
+
+// NewMockProvider creates a provider signing webhooks with the secret, payment urls lead to baseURL
+func NewMockProvider(secret, baseURL string) *MockProvider {
+	return &MockProvider{
+		secret:  []byte(secret),
+		baseURL: baseURL,
+		intents: make(map[string]uint64),
+	}
+}
+
+func (p *MockProvider) Name() string {
+	return MockProviderName
+}
+
+func (p *MockProvider) CreateIntent(_ context.Context, _ uint64, amount uint64, currency string) (*Intent, error) {
+	id, err := randomID("mock_pi_")
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.intents[id] = amount
+	p.mu.Unlock()
+
+	return &Intent{
+		ID:         id,
+		Amount:     amount,
+		Currency:   currency,
+		PaymentURL: fmt.Sprintf("%s/api/payments/mock/%s", p.baseURL, id),
+	}, nil
+}
+
+func (p *MockProvider) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.sign(payload)) {
+		return nil, SignatureErr
+	}
+
+	var event Event
+	if err = json.Unmarshal(payload, &event); err != nil {
+		return nil, WebhookPayloadErr
+	}
+	if event.ID == "" || event.IntentID == "" {
+		return nil, WebhookPayloadErr
+	}
+
+	return &event, nil
+}
+
+func (p *MockProvider) Refund(_ context.Context, _ string, _ uint64, _ string) (string, error) {
+	return randomID("mock_re_")
+}
+
+// Sign returns the signature the webhook of the provider expects for the payload
+func (p *MockProvider) Sign(payload []byte) string {
+	return hex.EncodeToString(p.sign(payload))
+}
+
+// Event builds a signed webhook payload for the intent as if the provider sent it
+func (p *MockProvider) Event(eventType, intentID string) ([]byte, string, error) {
+	p.mu.Lock()
+	amount, ok := p.intents[intentID]
+	p.mu.Unlock()
+	if !ok {
+		return nil, "", PaymentNotFoundErr
+	}
+
+	id, err := randomID("mock_evt_")
+	if err != nil {
+		return nil, "", err
+	}
+
+	payload, err := json.Marshal(&Event{ID: id, Type: eventType, IntentID: intentID, Amount: amount})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payload, p.Sign(payload), nil
+}
+
+func (p *MockProvider) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
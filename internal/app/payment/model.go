@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatusPending   = "Ожидает оплаты"
+	StatusSucceeded = "Оплачен"
+	StatusFailed    = "Ошибка"
+)
+
+// Statuses of the refunds sent to the provider
+const (
+	RefundPending   = "Ожидает возврата"
+	RefundSucceeded = "Возвращен"
+)
+
+type Payment struct {
+	ID         uint64    `db:"id"`
+	OrderID    uint64    `db:"order_id"`
+	Provider   string    `db:"provider"`
+	IntentID   string    `db:"intent_id"`
+	Status     string    `db:"status"`
+	Amount     uint64    `db:"amount"`
+	Currency   string    `db:"currency"`
+	Refunded   uint64    `db:"refunded_amount"`
+	PaymentURL string    `db:"payment_url"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+func (p *Payment) ToDTO() *DTO {
+	return &DTO{
+		ID:         p.ID,
+		OrderID:    p.OrderID,
+		Status:     p.Status,
+		Amount:     p.Amount,
+		Currency:   p.Currency,
+		Refunded:   p.Refunded,
+		PaymentURL: p.PaymentURL,
+		CreatedAt:  p.CreatedAt,
+	}
+}
+
+// Refund is the money of the payment returned to the customer, it's recorded before the provider is called
+// and sent to the provider after the commit until the provider confirms it
+type Refund struct {
+	ID               uint64    `db:"id"`
+	PaymentID        uint64    `db:"payment_id"`
+	IntentID         string    `db:"intent_id"`
+	Amount           uint64    `db:"amount"`
+	Status           string    `db:"status"`
+	ProviderRefundID *string   `db:"provider_refund_id"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+// IdempotencyKey lets the provider recognize the refund sent again
+func (r *Refund) IdempotencyKey() string {
+	return fmt.Sprintf("refund-%d", r.ID)
+}
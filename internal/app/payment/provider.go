@@ -0,0 +1,36 @@
+package payment
+
+import "context"
+
+const (
+	EventSucceeded = "payment.succeeded"
+	EventFailed    = "payment.failed"
+)
+
+// Provider is a payment service which takes money from customers and returns it back
+type Provider interface {
+	// Name identifies the provider in stored payments and webhook events
+	Name() string
+	// CreateIntent starts a payment of the amount in minor units of the currency,
+	// the customer pays it following the intent payment url
+	CreateIntent(ctx context.Context, orderID uint64, amount uint64, currency string) (*Intent, error)
+	// ParseWebhook verifies the signature of the webhook payload and returns the event it carries
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+	// Refund returns the amount of the paid intent to the customer and returns the id of the refund.
+	// Refunds sent again with the same idempotency key are returned only once
+	Refund(ctx context.Context, intentID string, amount uint64, idempotencyKey string) (string, error)
+}
+
+type Intent struct {
+	ID         string
+	Amount     uint64
+	Currency   string
+	PaymentURL string
+}
+
+type Event struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	IntentID string `json:"intent_id"`
+	Amount   uint64 `json:"amount"`
+}
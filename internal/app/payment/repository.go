@@ -0,0 +1,141 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type PaymentRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+func (r *PaymentRepository) Create(ctx context.Context, payment *Payment) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, `
+		INSERT INTO payments(order_id, provider, intent_id, status, amount, currency, payment_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		payment.OrderID, payment.Provider, payment.IntentID, payment.Status, payment.Amount, payment.Currency,
+		payment.PaymentURL).Scan(&id)
+	return id, errors.Wrapf(err, "error creating payment: %v", payment)
+}
+
+// ReadPendingByOrderID returns the latest payment of the order which is not paid yet
+func (r *PaymentRepository) ReadPendingByOrderID(ctx context.Context, orderID uint64) (*Payment, error) {
+	var p Payment
+	err := r.db.Get(ctx, &p, `
+		SELECT id, order_id, provider, intent_id, status, amount, currency, refunded_amount, payment_url, created_at, updated_at
+		FROM payments
+		WHERE order_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT 1`, orderID, StatusPending)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, PaymentNotFoundErr
+	}
+	return &p, errors.Wrapf(err, "error getting pending payment of order with id: %d", orderID)
+}
+
+func (r *PaymentRepository) ReadByOrderID(ctx context.Context, orderID uint64) ([]*Payment, error) {
+	payments := make([]*Payment, 0)
+	err := r.db.Select(ctx, &payments, `
+		SELECT id, order_id, provider, intent_id, status, amount, currency, refunded_amount, payment_url, created_at, updated_at
+		FROM payments
+		WHERE order_id = $1
+		ORDER BY created_at DESC`, orderID)
+	return payments, errors.Wrapf(err, "error getting payments of order with id: %d", orderID)
+}
+
+// LockByIntentID reads the payment of the provider intent and locks it until the end of the transaction
+func (r *PaymentRepository) LockByIntentID(ctx context.Context, provider, intentID string) (*Payment, error) {
+	var p Payment
+	err := r.db.Get(ctx, &p, `
+		SELECT id, order_id, provider, intent_id, status, amount, currency, refunded_amount, payment_url, created_at, updated_at
+		FROM payments
+		WHERE provider = $1 AND intent_id = $2
+		FOR UPDATE`, provider, intentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, PaymentNotFoundErr
+	}
+	return &p, errors.Wrapf(err, "error locking payment with intent id: %s", intentID)
+}
+
+// LockSucceededByOrderID reads the paid payment of the order and locks it until the end of the transaction
+func (r *PaymentRepository) LockSucceededByOrderID(ctx context.Context, orderID uint64) (*Payment, error) {
+	var p Payment
+	err := r.db.Get(ctx, &p, `
+		SELECT id, order_id, provider, intent_id, status, amount, currency, refunded_amount, payment_url, created_at, updated_at
+		FROM payments
+		WHERE order_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE`, orderID, StatusSucceeded)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, PaymentNotFoundErr
+	}
+	return &p, errors.Wrapf(err, "error locking paid payment of order with id: %d", orderID)
+}
+
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id uint64, status string) error {
+	_, err := r.db.Exec(ctx, "UPDATE payments SET status = $1, updated_at = NOW() WHERE id = $2", status, id)
+	return errors.Wrapf(err, "error updating status of payment with id: %d", id)
+}
+
+func (r *PaymentRepository) AddRefunded(ctx context.Context, id uint64, amount uint64) error {
+	_, err := r.db.Exec(ctx, "UPDATE payments SET refunded_amount = refunded_amount + $1, updated_at = NOW() WHERE id = $2",
+		amount, id)
+	return errors.Wrapf(err, "error adding refund to payment with id: %d", id)
+}
+
+func (r *PaymentRepository) CreateRefund(ctx context.Context, refund *Refund) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, "INSERT INTO payment_refunds(payment_id, amount, status) VALUES ($1, $2, $3) RETURNING id",
+		refund.PaymentID, refund.Amount, refund.Status).Scan(&id)
+	return id, errors.Wrapf(err, "error creating refund of payment with id: %d", refund.PaymentID)
+}
+
+// ReadPendingRefunds returns refunds the provider hasn't confirmed yet, the oldest first
+func (r *PaymentRepository) ReadPendingRefunds(ctx context.Context) ([]*Refund, error) {
+	refunds := make([]*Refund, 0)
+	err := r.db.Select(ctx, &refunds, `
+		SELECT payment_refunds.id, payment_refunds.payment_id, payments.intent_id, payment_refunds.amount,
+		       payment_refunds.status, payment_refunds.provider_refund_id, payment_refunds.created_at, payment_refunds.updated_at
+		FROM payment_refunds
+			JOIN payments ON payments.id = payment_refunds.payment_id
+		WHERE payment_refunds.status = $1
+		ORDER BY payment_refunds.id`, RefundPending)
+	return refunds, errors.Wrap(err, "error getting pending refunds")
+}
+
+func (r *PaymentRepository) CompleteRefund(ctx context.Context, id uint64, providerRefundID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE payment_refunds SET status = $1, provider_refund_id = $2, updated_at = NOW()
+		WHERE id = $3 AND status = $4`, RefundSucceeded, providerRefundID, id, RefundPending)
+	return errors.Wrapf(err, "error completing refund with id: %d", id)
+}
+
+// CreateEvent records the webhook event and reports whether it wasn't recorded before
+func (r *PaymentRepository) CreateEvent(ctx context.Context, provider string, event *Event) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO payment_events(id, provider, type, intent_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		event.ID, provider, event.Type, event.IntentID)
+	if err != nil {
+		return false, errors.Wrapf(err, "error recording payment event: %v", event)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,336 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context, payment *Payment) (uint64, error)
+	ReadPendingByOrderID(ctx context.Context, orderID uint64) (*Payment, error)
+	ReadByOrderID(ctx context.Context, orderID uint64) ([]*Payment, error)
+	LockByIntentID(ctx context.Context, provider, intentID string) (*Payment, error)
+	LockSucceededByOrderID(ctx context.Context, orderID uint64) (*Payment, error)
+	UpdateStatus(ctx context.Context, id uint64, status string) error
+	AddRefunded(ctx context.Context, id uint64, amount uint64) error
+	CreateRefund(ctx context.Context, refund *Refund) (uint64, error)
+	ReadPendingRefunds(ctx context.Context) ([]*Refund, error)
+	CompleteRefund(ctx context.Context, id uint64, providerRefundID string) error
+	CreateEvent(ctx context.Context, provider string, event *Event) (bool, error)
+}
+
+type OrderRepository interface {
+	LockById(ctx context.Context, id uint64) (*order.Order, error)
+	ReadByIdEager(ctx context.Context, id uint64) (*order.Order, error)
+}
+
+type OrderService interface {
+	ChangeStatus(ctx context.Context, id uint64, status string, actor *auth.UserData, comment string) (*order.DTO, error)
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
+}
+
+// systemActor changes order statuses on behalf of the store when the provider reports a payment
+var systemActor = &auth.UserData{Role: order.SystemRole}
+
+type PaymentService struct {
+	repository      Repository
+	orderRepository OrderRepository
+	orderService    OrderService
+	provider        Provider
+	transactor      Transactor
+}
+
+func NewService(repository Repository, orderRepository OrderRepository, orderService OrderService, provider Provider, transactor Transactor) *PaymentService {
+	return &PaymentService{
+		repository:      repository,
+		orderRepository: orderRepository,
+		orderService:    orderService,
+		provider:        provider,
+		transactor:      transactor,
+	}
+}
+
+// Create starts the payment of the user's order awaiting payment. The pending payment of the same amount
+// is returned again instead of creating a new one, so the customer can follow its payment url once more.
+// The provider is called between two transactions, so the order isn't locked while the provider answers,
+// and the order is checked again before the payment is saved. Orders with nothing to charge, e.g. covered
+// by a promo code, are paid at once without the provider
+func (s *PaymentService) Create(c echo.Context, orderID uint64, actor *auth.UserData) (*DTO, error) {
+	var o *order.Order
+	var p *Payment
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		o, err = s.payableOrder(ctx, orderID, actor)
+		if err != nil {
+			return err
+		}
+
+		if o.Charge() == 0 {
+			_, err = s.orderService.ChangeStatus(ctx, orderID, order.StatusPaid, systemActor, "сумма к оплате равна нулю")
+			return err
+		}
+
+		p, err = s.samePendingPayment(ctx, o)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if o.Charge() == 0 {
+		return &DTO{OrderID: orderID, Status: StatusSucceeded, Currency: o.Quote().Currency}, nil
+	}
+	if p != nil {
+		return p.ToDTO(), nil
+	}
+
+	intent, err := s.provider.CreateIntent(c.Request().Context(), orderID, o.Charge(), o.Quote().Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		o, err := s.payableOrder(ctx, orderID, actor)
+		if err != nil {
+			return err
+		}
+		if o.Charge() != intent.Amount || o.Quote().Currency != intent.Currency {
+			return PaymentNotAllowedErr
+		}
+
+		// a concurrent request may have saved its payment while the provider was answering
+		p, err = s.samePendingPayment(ctx, o)
+		if err != nil || p != nil {
+			return err
+		}
+
+		p = &Payment{
+			OrderID:    orderID,
+			Provider:   s.provider.Name(),
+			IntentID:   intent.ID,
+			Status:     StatusPending,
+			Amount:     intent.Amount,
+			Currency:   intent.Currency,
+			PaymentURL: intent.PaymentURL,
+		}
+		p.ID, err = s.repository.Create(ctx, p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ToDTO(), nil
+}
+
+// payableOrder locks the order of the user if it awaits payment
+func (s *PaymentService) payableOrder(ctx context.Context, orderID uint64, actor *auth.UserData) (*order.Order, error) {
+	o, err := s.orderRepository.LockById(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserID != actor.ID {
+		return nil, order.ForeignOrderErr
+	}
+	if o.Status != order.StatusAwaitingPayment {
+		return nil, PaymentNotAllowedErr
+	}
+	return o, nil
+}
+
+// samePendingPayment returns the pending payment of the order charging its current amount, nil if there is none
+func (s *PaymentService) samePendingPayment(ctx context.Context, o *order.Order) (*Payment, error) {
+	p, err := s.repository.ReadPendingByOrderID(ctx, o.ID)
+	if errors.Is(err, PaymentNotFoundErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Amount != o.Charge() || p.Currency != o.Quote().Currency {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// ReadByOrderID returns payments of the order, users can see only payments of their own orders
+func (s *PaymentService) ReadByOrderID(c echo.Context, orderID uint64, actor *auth.UserData) ([]*DTO, error) {
+	if actor.Role == "user" {
+		o, err := s.orderRepository.ReadByIdEager(c.Request().Context(), orderID)
+		if err != nil {
+			return nil, err
+		}
+		if o.UserID != actor.ID {
+			return nil, order.ForeignOrderErr
+		}
+	}
+
+	payments, err := s.repository.ReadByOrderID(c.Request().Context(), orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payments) == 0 {
+		return nil, PaymentNotFoundErr
+	}
+
+	dtos := make([]*DTO, 0, len(payments))
+	for _, p := range payments {
+		dtos = append(dtos, p.ToDTO())
+	}
+
+	return dtos, nil
+}
+
+// HandleWebhook applies the verified provider event. Every event is applied once, so providers may
+// deliver them again. A successful payment moves the order to paid, money paid for an order which
+// doesn't await payment anymore, e.g. cancelled or already paid, is returned to the customer
+func (s *PaymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		isNew, err := s.repository.CreateEvent(ctx, s.provider.Name(), event)
+		if err != nil || !isNew {
+			return err
+		}
+
+		p, err := s.repository.LockByIntentID(ctx, s.provider.Name(), event.IntentID)
+		if err != nil {
+			return err
+		}
+
+		switch event.Type {
+		case EventSucceeded:
+			return s.applySucceeded(ctx, p, event)
+		case EventFailed:
+			if p.Status != StatusPending {
+				return nil
+			}
+			return s.repository.UpdateStatus(ctx, p.ID, StatusFailed)
+		}
+
+		return nil
+	})
+}
+
+func (s *PaymentService) applySucceeded(ctx context.Context, p *Payment, event *Event) error {
+	if p.Status == StatusSucceeded {
+		return nil
+	}
+	if event.Amount != p.Amount {
+		return AmountMismatchErr
+	}
+
+	if err := s.repository.UpdateStatus(ctx, p.ID, StatusSucceeded); err != nil {
+		return err
+	}
+
+	o, err := s.orderRepository.LockById(ctx, p.OrderID)
+	if err != nil {
+		return err
+	}
+	if o.Status != order.StatusAwaitingPayment || o.Charge() != p.Amount {
+		log.Printf("payment %s of order %d with status %s is returned", p.IntentID, o.ID, o.Status)
+		return s.refund(ctx, p, p.Amount)
+	}
+
+	_, err = s.orderService.ChangeStatus(ctx, p.OrderID, order.StatusPaid, systemActor,
+		fmt.Sprintf("платеж %s", p.IntentID))
+	return err
+}
+
+// RefundOrder returns the amount in minor units of the base currency to the customer in the currency
+// the order was paid in. The refund completing the order returns the rest of the payment, so conversion
+// rounding never leaves a remainder. Orders paid outside of the provider have no payment and are refunded manually
+func (s *PaymentService) RefundOrder(ctx context.Context, orderID uint64, amount uint64) error {
+	p, err := s.repository.LockSucceededByOrderID(ctx, orderID)
+	if errors.Is(err, PaymentNotFoundErr) {
+		log.Printf("order %d has no payment to refund %d from, refund it manually", orderID, amount)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	o, err := s.orderRepository.LockById(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	charge := o.Quote().Convert(amount)
+	if rest := p.Amount - p.Refunded; o.Refunded >= o.Payable() || charge > rest {
+		charge = rest
+	}
+
+	return s.refund(ctx, p, charge)
+}
+
+// refund records the refund of the payment as pending, the provider is called once the transaction is committed,
+// so a rolled back or retried transaction never returns money which isn't recorded. Nothing is recorded for a zero amount
+func (s *PaymentService) refund(ctx context.Context, p *Payment, amount uint64) error {
+	if amount == 0 {
+		return nil
+	}
+	if p.Amount-p.Refunded < amount {
+		return RefundExceedsPaymentErr
+	}
+
+	if err := s.repository.AddRefunded(ctx, p.ID, amount); err != nil {
+		return err
+	}
+
+	refund := &Refund{
+		PaymentID: p.ID,
+		IntentID:  p.IntentID,
+		Amount:    amount,
+		Status:    RefundPending,
+	}
+	var err error
+	if refund.ID, err = s.repository.CreateRefund(ctx, refund); err != nil {
+		return err
+	}
+
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		if err := s.sendRefund(ctx, refund); err != nil {
+			log.Printf("refund %d of payment %s is left pending: %v", refund.ID, refund.IntentID, err)
+		}
+	})
+	return nil
+}
+
+// RetryRefunds sends pending refunds to the provider again, the provider returns each of them only once
+func (s *PaymentService) RetryRefunds(ctx context.Context) error {
+	refunds, err := s.repository.ReadPendingRefunds(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, refund := range refunds {
+		if err = s.sendRefund(ctx, refund); err != nil {
+			log.Printf("refund %d of payment %s is left pending: %v", refund.ID, refund.IntentID, err)
+		}
+	}
+	return nil
+}
+
+func (s *PaymentService) sendRefund(ctx context.Context, refund *Refund) error {
+	providerRefundID, err := s.provider.Refund(ctx, refund.IntentID, refund.Amount, refund.IdempotencyKey())
+	if err != nil {
+		return err
+	}
+	return s.repository.CompleteRefund(ctx, refund.ID, providerRefundID)
+}
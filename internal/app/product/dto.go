@@ -1,19 +1,27 @@
 package product
 
 import (
+	"time"
+
 	"github.com/Mickey327/rcsp-backend/internal/app/category"
 	"github.com/Mickey327/rcsp-backend/internal/app/company"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
 )
 
 type DTO struct {
-	ID          uint64        `json:"id,omitempty"`
-	Name        string        `json:"name,omitempty"`
-	Description string        `json:"description,omitempty"`
-	Price       uint64        `json:"price,omitempty"`
-	Stock       uint64        `json:"stock,omitempty"`
-	Image       string        `json:"image,omitempty"`
-	Category    *category.DTO `json:"category,omitempty"`
-	Company     *company.DTO  `json:"company,omitempty"`
+	ID             uint64        `json:"id,omitempty"`
+	Name           string        `json:"name,omitempty"`
+	Description    string        `json:"description,omitempty"`
+	Price          uint64        `json:"price,omitempty"`
+	EffectivePrice uint64        `json:"effective_price,omitempty"`
+	LowestPrice    uint64        `json:"lowest_price,omitempty"`
+	Stock          uint64        `json:"stock,omitempty"`
+	Available      uint64        `json:"available"`
+	Image          string        `json:"image,omitempty"`
+	Category       *category.DTO `json:"category,omitempty"`
+	Company        *company.DTO  `json:"company,omitempty"`
+	DeletedAt      *time.Time    `json:"deleted_at,omitempty"`
+	Currency       string        `json:"currency,omitempty"`
 }
 
 func (d *DTO) ToProduct() *Product {
@@ -34,3 +42,22 @@ func (d *DTO) ToProduct() *Product {
 
 	return product
 }
+
+// Convert shows prices of the product in the quoted currency, prices are given in its minor units
+func (d *DTO) Convert(q *currency.Quote) {
+	d.Currency = q.Currency
+	d.Price = q.Convert(d.Price)
+	d.EffectivePrice = q.Convert(d.EffectivePrice)
+	d.LowestPrice = q.Convert(d.LowestPrice)
+}
+
+type PriceChangeDTO struct {
+	Price     uint64    `json:"price"`
+	Currency  string    `json:"currency"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func (d *PriceChangeDTO) Convert(q *currency.Quote) {
+	d.Currency = q.Currency
+	d.Price = q.Convert(d.Price)
+}
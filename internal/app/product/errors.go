@@ -5,4 +5,5 @@ import "errors"
 var (
 	ProductNotFoundErr      = errors.New("товар не найден")
 	ProductAlreadyExistsErr = errors.New("товар с таким именем уже существует")
+	ProductInUseErr         = errors.New("товар присутствует в оформленных заказах и не может быть удален окончательно")
 )
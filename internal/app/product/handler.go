@@ -22,6 +22,10 @@ type Service interface {
 	ReadByCompanyIDAndCategoryID(c echo.Context, companyID, categoryID uint64) ([]*DTO, error)
 	Update(c echo.Context, productDTO *DTO) (bool, error)
 	Delete(c echo.Context, id uint64) (bool, error)
+	ReadDeleted(c echo.Context) ([]*DTO, error)
+	Restore(c echo.Context, id uint64) (bool, error)
+	Purge(c echo.Context, id uint64) (bool, error)
+	ReadPriceHistory(c echo.Context, id uint64) ([]*PriceChangeDTO, error)
 }
 
 type Handler struct {
@@ -243,3 +247,107 @@ func (h *Handler) Delete(c echo.Context) error {
 		"message": "товар был успешно удален",
 	})
 }
+
+func (h *Handler) ReadDeleted(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	productDTOs, err := h.service.ReadDeleted(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":     http.StatusOK,
+		"products": productDTOs,
+	})
+}
+
+func (h *Handler) Restore(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id товара")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id товара должно быть положительным")
+	}
+
+	isRestored, err := h.service.Restore(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isRestored {
+		return echo.NewHTTPError(http.StatusNotFound, ProductNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "товар был успешно восстановлен",
+	})
+}
+
+func (h *Handler) Purge(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id товара")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id товара должно быть положительным")
+	}
+
+	isPurged, err := h.service.Purge(c, id)
+	if err != nil {
+		if errors.Is(err, ProductInUseErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isPurged {
+		return echo.NewHTTPError(http.StatusNotFound, ProductNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "товар был успешно удален окончательно",
+	})
+}
+
+func (h *Handler) ReadPriceHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id товара")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id товара должно быть положительным")
+	}
+
+	prices, err := h.service.ReadPriceHistory(c, id)
+	if err != nil {
+		if errors.Is(err, ProductNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения истории цен товара")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"prices": prices,
+	})
+}
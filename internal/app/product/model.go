@@ -5,29 +5,39 @@ import (
 
 	"github.com/Mickey327/rcsp-backend/internal/app/category"
 	"github.com/Mickey327/rcsp-backend/internal/app/company"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
 )
 
 type Product struct {
-	ID          uint64             `db:"id"`
-	Name        string             `db:"name"`
-	Description string             `db:"description"`
-	Price       uint64             `db:"price"`
-	Stock       uint64             `db:"stock"`
-	Image       string             `db:"image"`
-	CreatedAt   time.Time          `db:"created_at"`
-	UpdatedAt   time.Time          `db:"updated_at"`
-	Category    *category.Category `scan:"notate"`
-	Company     *company.Company   `scan:"notate"`
+	ID             uint64             `db:"id"`
+	Name           string             `db:"name"`
+	Description    string             `db:"description"`
+	Price          uint64             `db:"price"`
+	EffectivePrice uint64             `db:"effective_price"`
+	LowestPrice    uint64             `db:"lowest_price"`
+	Stock          uint64             `db:"stock"`
+	Available      uint64             `db:"available"`
+	Image          string             `db:"image"`
+	CreatedAt      time.Time          `db:"created_at"`
+	UpdatedAt      time.Time          `db:"updated_at"`
+	DeletedAt      *time.Time         `db:"deleted_at"`
+	Category       *category.Category `scan:"notate"`
+	Company        *company.Company   `scan:"notate"`
 }
 
 func (p *Product) ToDTO() *DTO {
 	productDTO := &DTO{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		Stock:       p.Stock,
-		Image:       p.Image,
+		ID:             p.ID,
+		Name:           p.Name,
+		Description:    p.Description,
+		Price:          p.Price,
+		EffectivePrice: p.EffectivePrice,
+		LowestPrice:    p.LowestPrice,
+		Stock:          p.Stock,
+		Available:      p.Available,
+		Image:          p.Image,
+		Currency:       currency.Base,
+		DeletedAt:      p.DeletedAt,
 	}
 	if p.Company != nil {
 		productDTO.Company = p.Company.ToDTO()
@@ -48,3 +58,27 @@ func ToDTOs(products []*Product) []*DTO {
 
 	return productDTOs
 }
+
+// PriceChange is a price the product had since the time it was set
+type PriceChange struct {
+	Price     uint64    `db:"price"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+func (p *PriceChange) ToDTO() *PriceChangeDTO {
+	return &PriceChangeDTO{
+		Price:     p.Price,
+		Currency:  currency.Base,
+		ChangedAt: p.ChangedAt,
+	}
+}
+
+func PriceChangesToDTOs(changes []*PriceChange) []*PriceChangeDTO {
+	var changeDTOs []*PriceChangeDTO
+
+	for _, change := range changes {
+		changeDTOs = append(changeDTOs, change.ToDTO())
+	}
+
+	return changeDTOs
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -18,6 +19,19 @@ type DB interface {
 	GetPool() *pgxpool.Pool
 }
 
+// visibleProducts filters out archived products and products of archived categories or companies
+const visibleProducts = `products.deleted_at IS NULL
+	AND EXISTS (SELECT 1 FROM categories WHERE categories.id = products.category_id AND categories.deleted_at IS NULL)
+	AND EXISTS (SELECT 1 FROM companies WHERE companies.id = products.company_id AND companies.deleted_at IS NULL)`
+
+// effectivePrice selects the product price with the discount of the sales running now
+// and the lowest product price of the last 30 days the discount has to be compared with
+const effectivePrice = `effective_price(products.id, NOW()) as effective_price,
+		COALESCE(lowest_price(products.id, NOW() - INTERVAL '30 days'), products.price) as lowest_price`
+
+// availableStock selects the stock of the product without the quantities reserved in carts
+const availableStock = "available_stock(products.id, NULL) as available"
+
 type ProductRepository struct {
 	db DB
 }
@@ -26,9 +40,18 @@ func NewRepository(db DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
+// Create inserts the product and starts its price history
 func (r *ProductRepository) Create(ctx context.Context, product *Product) (uint64, error) {
 	var id uint64
-	err := r.db.ExecQueryRow(ctx, `INSERT INTO products(name, description, price, stock, image, category_id, company_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+	err := r.db.ExecQueryRow(ctx, `
+		WITH created AS (
+			INSERT INTO products(name, description, price, stock, image, category_id, company_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, price
+		), history AS (
+			INSERT INTO product_price_history(product_id, price) SELECT id, price FROM created
+		)
+		SELECT id FROM created`,
 		product.Name, product.Description, product.Price, product.Stock, product.Image, product.Category.ID, product.Company.ID).Scan(&id)
 	return id, errors.Wrapf(err, "error creating product: %v", product)
 }
@@ -36,14 +59,14 @@ func (r *ProductRepository) Create(ctx context.Context, product *Product) (uint6
 func (r *ProductRepository) Read(ctx context.Context, id uint64) (*Product, error) {
 	var p Product
 	err := r.db.Get(ctx, &p, `
-		SELECT products.id, products.name, products.description, products.price, products.stock, products.image, 
+		SELECT products.id, products.name, products.description, products.price, `+effectivePrice+`, products.stock, `+availableStock+`, products.image,
 		       products.category_id as "category.id", products.company_id as "company.id", products.created_at, products.updated_at 
 		FROM products 
-		WHERE id = $1`, id)
+		WHERE id = $1 AND `+visibleProducts, id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ProductNotFoundErr
 	}
-	return &p, nil
+	return &p, errors.Wrapf(err, "error getting product with id: %d", id)
 }
 
 func (r *ProductRepository) ReadEager(ctx context.Context, id uint64) (*Product, error) {
@@ -51,36 +74,37 @@ func (r *ProductRepository) ReadEager(ctx context.Context, id uint64) (*Product,
 	err := r.db.Get(ctx, &p,
 		`
 		SELECT 
-        	products.id, products.name, products.description, products.price, products.stock,
+        	products.id, products.name, products.description, products.price, `+effectivePrice+`, products.stock, `+availableStock+`,
         	products.image, products.created_at, products.updated_at,
         	c.id as "category.id", c.name as "category.name", c.updated_at as "category.updated_at", c.created_at as "category.created_at",
        		c2.id as "company.id", c2.name as "company.name", c2.updated_at as "company.updated_at", c2.created_at as "company.created_at"
 		FROM products
 			JOIN categories c on products.category_id = c.id
 			JOIN companies c2 on c2.id = products.company_id
-		WHERE products.id = $1
+		WHERE products.id = $1 AND products.deleted_at IS NULL AND c.deleted_at IS NULL AND c2.deleted_at IS NULL
 		`, id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ProductNotFoundErr
 	}
-	return &p, nil
+	return &p, errors.Wrapf(err, "error getting product with id: %d", id)
 }
 
 func (r *ProductRepository) ReadAll(ctx context.Context) ([]*Product, error) {
 	products := make([]*Product, 0)
 	err := r.db.Select(ctx, &products,
 		`SELECT 
-    			id, name, description, price, stock, image,
+    			id, name, description, price, `+effectivePrice+`, stock, `+availableStock+`, image,
     			category_id as "category.id", company_id as "company.id", 
     			created_at, updated_at 
-				FROM products`)
+				FROM products
+				WHERE `+visibleProducts)
 	return products, errors.Wrap(err, "error getting products")
 }
 
 func (r *ProductRepository) ReadByCategoryID(ctx context.Context, categoryID uint64) ([]*Product, error) {
 	products := make([]*Product, 0)
 	err := r.db.Select(ctx, &products,
-		`SELECT id, name, description, price, stock, image, category_id as "category.id", company_id as "company.id", created_at, updated_at FROM products WHERE category_id = $1`,
+		`SELECT id, name, description, price, `+effectivePrice+`, stock, `+availableStock+`, image, category_id as "category.id", company_id as "company.id", created_at, updated_at FROM products WHERE category_id = $1 AND `+visibleProducts,
 		categoryID)
 	return products, errors.Wrapf(err, "error getting products by category id: %d", categoryID)
 }
@@ -88,7 +112,7 @@ func (r *ProductRepository) ReadByCategoryID(ctx context.Context, categoryID uin
 func (r *ProductRepository) ReadByCompanyID(ctx context.Context, companyID uint64) ([]*Product, error) {
 	products := make([]*Product, 0)
 	err := r.db.Select(ctx, &products,
-		`SELECT id, name, description, price, stock, image, category_id as "category.id", company_id as "company.id", created_at, updated_at FROM products WHERE company_id = $1`,
+		`SELECT id, name, description, price, `+effectivePrice+`, stock, `+availableStock+`, image, category_id as "category.id", company_id as "company.id", created_at, updated_at FROM products WHERE company_id = $1 AND `+visibleProducts,
 		companyID)
 	return products, errors.Wrapf(err, "error getting products by category id: %d", companyID)
 }
@@ -96,20 +120,80 @@ func (r *ProductRepository) ReadByCompanyID(ctx context.Context, companyID uint6
 func (r *ProductRepository) ReadByCompanyIDAndCategoryID(ctx context.Context, companyID, categoryID uint64) ([]*Product, error) {
 	products := make([]*Product, 0)
 	err := r.db.Select(ctx, &products,
-		`SELECT id, name, description, price, stock, image, category_id as "category.id", company_id as "company.id", created_at, updated_at FROM products WHERE company_id = $1 AND category_id = $2`,
+		`SELECT id, name, description, price, `+effectivePrice+`, stock, `+availableStock+`, image, category_id as "category.id", company_id as "company.id", created_at, updated_at FROM products WHERE company_id = $1 AND category_id = $2 AND `+visibleProducts,
 		companyID, categoryID)
 	return products, errors.Wrapf(err, "error getting products by company id and category id: %d; %d", companyID, categoryID)
 }
 
+// Update changes the product and records the new price in the price history if the price has changed.
+// Users subscribed to the product in their wishlists are alerted when its price drops or it's back in stock
 func (r *ProductRepository) Update(ctx context.Context, product *Product) (bool, error) {
 	product.UpdatedAt = time.Now().UTC()
-	result, err := r.db.Exec(ctx,
-		"UPDATE products SET name = $1, description = $2, price = $3, stock = $4, image = $5, category_id = $6, company_id = $7, updated_at = $8 WHERE id = $9",
-		product.Name, product.Description, product.Price, product.Stock, product.Image, product.Category.ID, product.Company.ID, product.UpdatedAt, product.ID)
-	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating product: %v", product)
+	var count int
+	err := r.db.ExecQueryRow(ctx, `
+		WITH updated AS (
+			UPDATE products
+			SET name = $1, description = $2, price = $3, stock = $4, image = $5, category_id = $6, company_id = $7, updated_at = $8
+			FROM (SELECT price as old_price, stock as old_stock FROM products WHERE id = $9) old
+			WHERE id = $9 AND deleted_at IS NULL
+			RETURNING products.id, products.price, products.stock, old.old_price, old.old_stock
+		), history AS (
+			INSERT INTO product_price_history(product_id, price, changed_at)
+			SELECT id, price, $8 FROM updated WHERE price <> old_price
+		), alerts AS (
+			INSERT INTO wishlist_alerts(user_id, product_id, kind, old_price, price)
+			SELECT wishlist_items.user_id, updated.id,
+			       CASE WHEN updated.old_stock = 0 AND updated.stock > 0 THEN 'back_in_stock' ELSE 'price_drop' END,
+			       updated.old_price, updated.price
+			FROM updated
+				JOIN wishlist_items ON wishlist_items.product_id = updated.id AND wishlist_items.notify
+			WHERE (updated.old_stock = 0 AND updated.stock > 0) OR updated.price < updated.old_price
+		)
+		SELECT COUNT(*) FROM updated`,
+		product.Name, product.Description, product.Price, product.Stock, product.Image, product.Category.ID, product.Company.ID, product.UpdatedAt, product.ID).Scan(&count)
+	return count > 0, errors.Wrapf(err, "error updating product: %v", product)
 }
 
 func (r *ProductRepository) Delete(ctx context.Context, id uint64) (bool, error) {
-	result, err := r.db.Exec(ctx, "DELETE FROM products WHERE id = $1", id)
+	result, err := r.db.Exec(ctx, "UPDATE products SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", time.Now().UTC(), id)
 	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting product with id: %d", id)
 }
+
+func (r *ProductRepository) ReadDeleted(ctx context.Context) ([]*Product, error) {
+	products := make([]*Product, 0)
+	err := r.db.Select(ctx, &products,
+		`SELECT id, name, description, price, `+effectivePrice+`, stock, `+availableStock+`, image, category_id as "category.id", company_id as "company.id", created_at, updated_at, deleted_at FROM products WHERE deleted_at IS NOT NULL`)
+	return products, errors.Wrap(err, "error getting deleted products")
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "UPDATE products SET deleted_at = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL", time.Now().UTC(), id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error restoring product with id: %d", id)
+}
+
+// Purge permanently removes archived product. The product is dropped from carts that are still being arranged,
+// but arranged orders keep referencing it, so such a product can't be purged.
+func (r *ProductRepository) Purge(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		WITH cart_items AS (
+			DELETE FROM order_items
+			USING orders
+			WHERE orders.id = order_items.order_id AND orders.is_arranged = false AND order_items.product_id = $1
+		)
+		DELETE FROM products WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if repository.IsForeignKeyViolation(err) {
+		return false, ProductInUseErr
+	}
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error purging product with id: %d", id)
+}
+
+// ReadPriceHistory returns every price of the product from the oldest one
+func (r *ProductRepository) ReadPriceHistory(ctx context.Context, id uint64) ([]*PriceChange, error) {
+	changes := make([]*PriceChange, 0)
+	err := r.db.Select(ctx, &changes, `
+		SELECT price, changed_at
+		FROM product_price_history
+		WHERE product_id = $1
+		ORDER BY changed_at, id`, id)
+	return changes, errors.Wrapf(err, "error getting price history of product with id: %d", id)
+}
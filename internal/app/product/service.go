@@ -7,6 +7,7 @@ import (
 	"mime/multipart"
 	"os"
 
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,15 +21,26 @@ type Repository interface {
 	ReadByCompanyIDAndCategoryID(ctx context.Context, companyID, categoryID uint64) ([]*Product, error)
 	Update(ctx context.Context, product *Product) (bool, error)
 	Delete(ctx context.Context, id uint64) (bool, error)
+	ReadDeleted(ctx context.Context) ([]*Product, error)
+	Restore(ctx context.Context, id uint64) (bool, error)
+	Purge(ctx context.Context, id uint64) (bool, error)
+	ReadPriceHistory(ctx context.Context, id uint64) ([]*PriceChange, error)
+}
+
+// Currencies quotes the currency the client asked prices to be shown in
+type Currencies interface {
+	DisplayQuote(ctx context.Context) *currency.Quote
 }
 
 type ProductService struct {
 	repository Repository
+	currencies Currencies
 }
 
-func NewService(repository Repository) *ProductService {
+func NewService(repository Repository, currencies Currencies) *ProductService {
 	return &ProductService{
 		repository: repository,
+		currencies: currencies,
 	}
 }
 
@@ -73,7 +85,7 @@ func (s *ProductService) Read(c echo.Context, id uint64) (*DTO, error) {
 		return nil, err
 	}
 
-	return product.ToDTO(), nil
+	return s.display(c, product.ToDTO())[0], nil
 }
 
 func (s *ProductService) ReadEager(c echo.Context, id uint64) (*DTO, error) {
@@ -83,7 +95,7 @@ func (s *ProductService) ReadEager(c echo.Context, id uint64) (*DTO, error) {
 		return nil, err
 	}
 
-	return product.ToDTO(), nil
+	return s.display(c, product.ToDTO())[0], nil
 }
 
 func (s *ProductService) ReadAll(c echo.Context) ([]*DTO, error) {
@@ -97,7 +109,7 @@ func (s *ProductService) ReadAll(c echo.Context) ([]*DTO, error) {
 		return nil, ProductNotFoundErr
 	}
 
-	return ToDTOs(products), nil
+	return s.display(c, ToDTOs(products)...), nil
 }
 
 func (s *ProductService) ReadByCategoryID(c echo.Context, categoryID uint64) ([]*DTO, error) {
@@ -111,7 +123,7 @@ func (s *ProductService) ReadByCategoryID(c echo.Context, categoryID uint64) ([]
 		return nil, ProductNotFoundErr
 	}
 
-	return ToDTOs(products), nil
+	return s.display(c, ToDTOs(products)...), nil
 }
 
 func (s *ProductService) ReadByCompanyID(c echo.Context, companyID uint64) ([]*DTO, error) {
@@ -125,7 +137,7 @@ func (s *ProductService) ReadByCompanyID(c echo.Context, companyID uint64) ([]*D
 		return nil, ProductNotFoundErr
 	}
 
-	return ToDTOs(products), nil
+	return s.display(c, ToDTOs(products)...), nil
 }
 
 func (s *ProductService) ReadByCompanyIDAndCategoryID(c echo.Context, companyID, categoryID uint64) ([]*DTO, error) {
@@ -139,7 +151,7 @@ func (s *ProductService) ReadByCompanyIDAndCategoryID(c echo.Context, companyID,
 		return nil, ProductNotFoundErr
 	}
 
-	return ToDTOs(products), nil
+	return s.display(c, ToDTOs(products)...), nil
 }
 
 func (s *ProductService) Update(c echo.Context, productDTO *DTO) (bool, error) {
@@ -161,3 +173,66 @@ func (s *ProductService) Delete(c echo.Context, id uint64) (bool, error) {
 
 	return isDeleted, nil
 }
+
+func (s *ProductService) ReadDeleted(c echo.Context) ([]*DTO, error) {
+	products, err := s.repository.ReadDeleted(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, ProductNotFoundErr
+	}
+
+	return ToDTOs(products), nil
+}
+
+func (s *ProductService) Restore(c echo.Context, id uint64) (bool, error) {
+	isRestored, err := s.repository.Restore(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isRestored, nil
+}
+
+func (s *ProductService) Purge(c echo.Context, id uint64) (bool, error) {
+	isPurged, err := s.repository.Purge(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isPurged, nil
+}
+
+// ReadPriceHistory returns the price timeline of the visible product
+func (s *ProductService) ReadPriceHistory(c echo.Context, id uint64) ([]*PriceChangeDTO, error) {
+	if _, err := s.repository.Read(c.Request().Context(), id); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.repository.ReadPriceHistory(c.Request().Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	changeDTOs := PriceChangesToDTOs(changes)
+	q := s.currencies.DisplayQuote(c.Request().Context())
+	for _, changeDTO := range changeDTOs {
+		changeDTO.Convert(q)
+	}
+
+	return changeDTOs, nil
+}
+
+// display converts prices of the products to the currency requested by the client
+func (s *ProductService) display(c echo.Context, productDTOs ...*DTO) []*DTO {
+	q := s.currencies.DisplayQuote(c.Request().Context())
+	for _, productDTO := range productDTOs {
+		productDTO.Convert(q)
+	}
+	return productDTOs
+}
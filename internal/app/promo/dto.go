@@ -0,0 +1,49 @@
+package promo
+
+import (
+	"strings"
+	"time"
+)
+
+type DTO struct {
+	ID           uint64     `json:"id"`
+	Code         string     `json:"code"`
+	Type         string     `json:"type"`
+	Value        uint64     `json:"value"`
+	MinTotal     uint64     `json:"min_total"`
+	UsageLimit   *uint64    `json:"usage_limit,omitempty"`
+	PerUserLimit *uint64    `json:"per_user_limit,omitempty"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	CategoryIDs  []uint64   `json:"category_ids,omitempty"`
+	CompanyIDs   []uint64   `json:"company_ids,omitempty"`
+	ProductIDs   []uint64   `json:"product_ids,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (d *DTO) ToPromoCode() *PromoCode {
+	return &PromoCode{
+		ID:           d.ID,
+		Code:         NormalizeCode(d.Code),
+		Type:         d.Type,
+		Value:        d.Value,
+		MinTotal:     d.MinTotal,
+		UsageLimit:   d.UsageLimit,
+		PerUserLimit: d.PerUserLimit,
+		StartsAt:     d.StartsAt,
+		EndsAt:       d.EndsAt,
+		CategoryIDs:  d.CategoryIDs,
+		CompanyIDs:   d.CompanyIDs,
+		ProductIDs:   d.ProductIDs,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+type ApplyDTO struct {
+	Code string `json:"code"`
+}
+
+// NormalizeCode makes codes case insensitive
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,15 @@
+package promo
+
+import "errors"
+
+var (
+	PromoCodeNotFoundErr      = errors.New("промокод не найден")
+	PromoCodeAlreadyExistsErr = errors.New("промокод уже существует")
+	PromoCodeNotStartedErr    = errors.New("промокод еще не действует")
+	PromoCodeExpiredErr       = errors.New("срок действия промокода истек")
+	PromoCodeExhaustedErr     = errors.New("промокод больше не действует")
+	PromoCodeUserLimitErr     = errors.New("вы уже использовали этот промокод")
+	PromoCodeMinTotalErr      = errors.New("сумма заказа меньше минимальной для промокода")
+	PromoCodeNotApplicableErr = errors.New("промокод не действует на товары в корзине")
+	PromoCodeInvalidErr       = errors.New("данные промокода представлены в неверном формате")
+)
@@ -0,0 +1,126 @@
+package promo
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Create(c echo.Context, dto *DTO) (uint64, error)
+	Read(c echo.Context, id uint64) (*DTO, error)
+	ReadAll(c echo.Context) ([]*DTO, error)
+	Delete(c echo.Context, id uint64) (bool, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	promoCodeDTO := DTO{}
+
+	if err = c.Bind(&promoCodeDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	id, err := h.service.Create(c, &promoCodeDTO)
+	if err != nil {
+		if errors.Is(err, PromoCodeInvalidErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, PromoCodeAlreadyExistsErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка создания промокода")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"id":      id,
+		"message": "промокод был успешно создан",
+	})
+}
+
+func (h *Handler) Read(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id промокода")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id промокода должно быть положительным")
+	}
+
+	promoCodeDTO, err := h.service.Read(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"promo": promoCodeDTO,
+	})
+}
+
+func (h *Handler) ReadAll(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	promoCodeDTOs, err := h.service.ReadAll(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"promos": promoCodeDTOs,
+	})
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id промокода")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id промокода должно быть положительным")
+	}
+
+	isDeleted, err := h.service.Delete(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isDeleted {
+		return echo.NewHTTPError(http.StatusNotFound, PromoCodeNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "промокод был успешно удален",
+	})
+}
@@ -0,0 +1,144 @@
+package promo
+
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+)
+
+const (
+	TypePercent = "percent"
+	TypeFixed   = "fixed"
+)
+
+type PromoCode struct {
+	ID           uint64     `db:"id"`
+	Code         string     `db:"code"`
+	Type         string     `db:"discount_type"`
+	Value        uint64     `db:"value"`
+	MinTotal     uint64     `db:"min_total"`
+	UsageLimit   *uint64    `db:"usage_limit"`
+	PerUserLimit *uint64    `db:"per_user_limit"`
+	StartsAt     *time.Time `db:"starts_at"`
+	EndsAt       *time.Time `db:"ends_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+	CategoryIDs  []uint64
+	CompanyIDs   []uint64
+	ProductIDs   []uint64
+}
+
+// Usage counts orders the promo code was redeemed in, cancelled and refunded orders aren't counted
+type Usage struct {
+	Total  uint64 `db:"total"`
+	ByUser uint64 `db:"by_user"`
+}
+
+// Validate checks the validity window and usage limits of the promo code and the minimum order total.
+// The total is taken less the bundle savings, the same base the discount is calculated from
+func (p *PromoCode) Validate(o *order.Order, usage *Usage, now time.Time) error {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return PromoCodeNotStartedErr
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return PromoCodeExpiredErr
+	}
+	if p.UsageLimit != nil && usage.Total >= *p.UsageLimit {
+		return PromoCodeExhaustedErr
+	}
+	if p.PerUserLimit != nil && usage.ByUser >= *p.PerUserLimit {
+		return PromoCodeUserLimitErr
+	}
+	if o.Total < p.MinTotal {
+		return PromoCodeMinTotalErr
+	}
+	return nil
+}
+
+// Discount returns the discount the promo code gives to the order. Only items matching the restrictions
+// of the promo code are discounted and the fixed discount can't exceed their total. The bundle savings are
+// split between the items in proportion to their amounts like taxes do, so items of bundles are discounted
+// at their bundle prices rather than twice
+func (p *PromoCode) Discount(o *order.Order) (uint64, error) {
+	var eligible, subtotal uint64
+	for _, item := range o.OrderItems {
+		if item.Quantity <= 0 {
+			continue
+		}
+		amount := item.Price * uint64(item.Quantity)
+		subtotal += amount
+		if p.appliesTo(item) {
+			eligible += amount
+		}
+	}
+	if bundleDiscount := o.BundleDiscount(); bundleDiscount > 0 && subtotal > 0 {
+		eligible -= eligible * bundleDiscount / subtotal
+	}
+	if eligible == 0 {
+		return 0, PromoCodeNotApplicableErr
+	}
+
+	if p.Type == TypePercent {
+		return eligible * p.Value / 100, nil
+	}
+	if p.Value > eligible {
+		return eligible, nil
+	}
+	return p.Value, nil
+}
+
+// appliesTo reports whether the item matches any restriction of the promo code, promo code without
+// restrictions applies to every item
+func (p *PromoCode) appliesTo(item *orderItem.OrderItem) bool {
+	if len(p.CategoryIDs) == 0 && len(p.CompanyIDs) == 0 && len(p.ProductIDs) == 0 {
+		return true
+	}
+	if item.Product == nil {
+		return false
+	}
+	if contains(p.ProductIDs, item.Product.ID) {
+		return true
+	}
+	if item.Product.Category != nil && contains(p.CategoryIDs, item.Product.Category.ID) {
+		return true
+	}
+	return item.Product.Company != nil && contains(p.CompanyIDs, item.Product.Company.ID)
+}
+
+func contains(ids []uint64, id uint64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PromoCode) ToDTO() *DTO {
+	return &DTO{
+		ID:           p.ID,
+		Code:         p.Code,
+		Type:         p.Type,
+		Value:        p.Value,
+		MinTotal:     p.MinTotal,
+		UsageLimit:   p.UsageLimit,
+		PerUserLimit: p.PerUserLimit,
+		StartsAt:     p.StartsAt,
+		EndsAt:       p.EndsAt,
+		CategoryIDs:  p.CategoryIDs,
+		CompanyIDs:   p.CompanyIDs,
+		ProductIDs:   p.ProductIDs,
+		CreatedAt:    p.CreatedAt,
+	}
+}
+
+func ToDTOs(promoCodes []*PromoCode) []*DTO {
+	var promoCodeDTOs []*DTO
+
+	for _, promoCode := range promoCodes {
+		promoCodeDTOs = append(promoCodeDTOs, promoCode.ToDTO())
+	}
+
+	return promoCodeDTOs
+}
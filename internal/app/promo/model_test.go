@@ -0,0 +1,96 @@
+package promo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/category"
+	"github.com/Mickey327/rcsp-backend/internal/app/company"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+)
+
+func limit(v uint64) *uint64 {
+	return &v
+}
+
+func at(t time.Time) *time.Time {
+	return &t
+}
+
+func TestPromoCodeValidate(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	o := &order.Order{Total: 5000}
+
+	tests := []struct {
+		name  string
+		promo *PromoCode
+		usage *Usage
+		want  error
+	}{
+		{name: "no restrictions", promo: &PromoCode{}, usage: &Usage{}},
+		{name: "inside validity window", promo: &PromoCode{StartsAt: at(now.Add(-time.Hour)), EndsAt: at(now.Add(time.Hour))}, usage: &Usage{}},
+		{name: "starts exactly now", promo: &PromoCode{StartsAt: at(now)}, usage: &Usage{}},
+		{name: "not started", promo: &PromoCode{StartsAt: at(now.Add(time.Minute))}, usage: &Usage{}, want: PromoCodeNotStartedErr},
+		{name: "ends exactly now", promo: &PromoCode{EndsAt: at(now)}, usage: &Usage{}, want: PromoCodeExpiredErr},
+		{name: "expired", promo: &PromoCode{EndsAt: at(now.Add(-time.Minute))}, usage: &Usage{}, want: PromoCodeExpiredErr},
+		{name: "usage below limit", promo: &PromoCode{UsageLimit: limit(10)}, usage: &Usage{Total: 9}},
+		{name: "usage limit reached", promo: &PromoCode{UsageLimit: limit(10)}, usage: &Usage{Total: 10}, want: PromoCodeExhaustedErr},
+		{name: "per user limit reached", promo: &PromoCode{PerUserLimit: limit(1)}, usage: &Usage{Total: 3, ByUser: 1}, want: PromoCodeUserLimitErr},
+		{name: "total equals minimum", promo: &PromoCode{MinTotal: 5000}, usage: &Usage{}},
+		{name: "total below minimum", promo: &PromoCode{MinTotal: 5001}, usage: &Usage{}, want: PromoCodeMinTotalErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.promo.Validate(o, tt.usage, now); err != tt.want {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoCodeDiscount(t *testing.T) {
+	phone := &product.Product{ID: 1, Category: &category.Category{ID: 10}, Company: &company.Company{ID: 100}}
+	accessory := &product.Product{ID: 2, Category: &category.Category{ID: 20}, Company: &company.Company{ID: 200}}
+	// 2 phones of 3000 and a case of 1000
+	items := []*orderItem.OrderItem{
+		{Product: phone, Quantity: 2, Price: 3000},
+		{Product: accessory, Quantity: 1, Price: 1000},
+		{Product: phone, Quantity: 0, Price: 3000},
+	}
+	o := &order.Order{Total: 7000, OrderItems: items}
+	bundled := &order.Order{Total: 6300, OrderItems: items}
+
+	tests := []struct {
+		name    string
+		promo   *PromoCode
+		order   *order.Order
+		want    uint64
+		wantErr error
+	}{
+		{name: "percent of every item", promo: &PromoCode{Type: TypePercent, Value: 10}, order: o, want: 700},
+		{name: "fixed", promo: &PromoCode{Type: TypeFixed, Value: 500}, order: o, want: 500},
+		{name: "fixed is capped by eligible items", promo: &PromoCode{Type: TypeFixed, Value: 5000, ProductIDs: []uint64{2}}, order: o, want: 1000},
+		{name: "restricted to product", promo: &PromoCode{Type: TypePercent, Value: 10, ProductIDs: []uint64{2}}, order: o, want: 100},
+		{name: "restricted to category", promo: &PromoCode{Type: TypePercent, Value: 10, CategoryIDs: []uint64{10}}, order: o, want: 600},
+		{name: "restricted to company", promo: &PromoCode{Type: TypePercent, Value: 50, CompanyIDs: []uint64{200}}, order: o, want: 500},
+		{name: "no eligible items", promo: &PromoCode{Type: TypePercent, Value: 10, ProductIDs: []uint64{3}}, order: o, wantErr: PromoCodeNotApplicableErr},
+		{name: "bundle savings are split between items", promo: &PromoCode{Type: TypePercent, Value: 10}, order: bundled, want: 630},
+		{name: "restricted item at bundle price", promo: &PromoCode{Type: TypePercent, Value: 10, ProductIDs: []uint64{1}}, order: bundled, want: 540},
+		{name: "empty order", promo: &PromoCode{Type: TypeFixed, Value: 500}, order: &order.Order{}, wantErr: PromoCodeNotApplicableErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.promo.Discount(tt.order)
+			if err != tt.wantErr {
+				t.Fatalf("Discount() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Discount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
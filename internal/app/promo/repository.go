@@ -0,0 +1,174 @@
+package promo
+
+import (
+	"context"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+const promoCodeColumns = `id, code, discount_type, value, min_total, usage_limit, per_user_limit, starts_at, ends_at,
+		       created_at, updated_at`
+
+// restrictionTables maps tables restricting promo codes to the columns referencing restricted entities
+var restrictionTables = []struct {
+	table  string
+	column string
+	ids    func(p *PromoCode) *[]uint64
+}{
+	{"promo_code_categories", "category_id", func(p *PromoCode) *[]uint64 { return &p.CategoryIDs }},
+	{"promo_code_companies", "company_id", func(p *PromoCode) *[]uint64 { return &p.CompanyIDs }},
+	{"promo_code_products", "product_id", func(p *PromoCode) *[]uint64 { return &p.ProductIDs }},
+}
+
+type PromoRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *PromoRepository {
+	return &PromoRepository{db: db}
+}
+
+// Create inserts the promo code with its restrictions, it must be called inside a transaction
+func (r *PromoRepository) Create(ctx context.Context, promoCode *PromoCode) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, `
+		INSERT INTO promo_codes(code, discount_type, value, min_total, usage_limit, per_user_limit, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		promoCode.Code, promoCode.Type, promoCode.Value, promoCode.MinTotal, promoCode.UsageLimit,
+		promoCode.PerUserLimit, promoCode.StartsAt, promoCode.EndsAt).Scan(&id)
+	if repository.IsUniqueViolation(err) {
+		return 0, PromoCodeAlreadyExistsErr
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "error creating promo code: %v", promoCode)
+	}
+
+	for _, restriction := range restrictionTables {
+		for _, restrictedID := range *restriction.ids(promoCode) {
+			_, err = r.db.Exec(ctx,
+				"INSERT INTO "+restriction.table+"(promo_code_id, "+restriction.column+") VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				id, restrictedID)
+			if repository.IsForeignKeyViolation(err) {
+				return 0, PromoCodeInvalidErr
+			}
+			if err != nil {
+				return 0, errors.Wrapf(err, "error restricting promo code: %v", promoCode)
+			}
+		}
+	}
+
+	return id, nil
+}
+
+func (r *PromoRepository) Read(ctx context.Context, id uint64) (*PromoCode, error) {
+	var p PromoCode
+	err := r.db.Get(ctx, &p, "SELECT "+promoCodeColumns+" FROM promo_codes WHERE id = $1", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, PromoCodeNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting promo code with id: %d", id)
+	}
+
+	return &p, r.readRestrictions(ctx, &p)
+}
+
+func (r *PromoRepository) ReadByCode(ctx context.Context, code string) (*PromoCode, error) {
+	var p PromoCode
+	err := r.db.Get(ctx, &p, "SELECT "+promoCodeColumns+" FROM promo_codes WHERE code = $1", code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, PromoCodeNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting promo code: %s", code)
+	}
+
+	return &p, r.readRestrictions(ctx, &p)
+}
+
+// LockById reads the promo code and locks it until the end of the transaction, so concurrent
+// checkouts can't exceed its usage limits
+func (r *PromoRepository) LockById(ctx context.Context, id uint64) (*PromoCode, error) {
+	var p PromoCode
+	err := r.db.Get(ctx, &p, "SELECT "+promoCodeColumns+" FROM promo_codes WHERE id = $1 FOR UPDATE", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, PromoCodeNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error locking promo code with id: %d", id)
+	}
+
+	return &p, r.readRestrictions(ctx, &p)
+}
+
+func (r *PromoRepository) ReadAll(ctx context.Context) ([]*PromoCode, error) {
+	promoCodes := make([]*PromoCode, 0)
+	err := r.db.Select(ctx, &promoCodes, "SELECT "+promoCodeColumns+" FROM promo_codes ORDER BY created_at DESC")
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting promo codes")
+	}
+
+	for _, p := range promoCodes {
+		if err = r.readRestrictions(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+
+	return promoCodes, nil
+}
+
+// Delete removes the promo code, carts it was applied to lose their discount while arranged orders keep it
+func (r *PromoRepository) Delete(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		WITH carts AS (
+			UPDATE orders SET discount = 0, updated_at = NOW() WHERE promo_code_id = $1 AND is_arranged = false
+		)
+		DELETE FROM promo_codes WHERE id = $1`, id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting promo code with id: %d", id)
+}
+
+// ReadUsage counts orders of all users and of the user the promo code was redeemed in
+func (r *PromoRepository) ReadUsage(ctx context.Context, id, userID uint64) (*Usage, error) {
+	var usage Usage
+	err := r.db.Get(ctx, &usage, `
+		SELECT COUNT(*) as total, COUNT(*) FILTER (WHERE promo_code_usages.user_id = $2) as by_user
+		FROM promo_code_usages
+			JOIN orders ON orders.id = promo_code_usages.order_id
+		WHERE promo_code_usages.promo_code_id = $1 AND orders.status NOT IN ($3, $4)`,
+		id, userID, order.StatusCancelled, order.StatusRefunded)
+	return &usage, errors.Wrapf(err, "error counting usages of promo code with id: %d", id)
+}
+
+func (r *PromoRepository) CreateUsage(ctx context.Context, id uint64, o *order.Order) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO promo_code_usages(promo_code_id, order_id, user_id) VALUES ($1, $2, $3)
+		ON CONFLICT (order_id) DO UPDATE SET promo_code_id = EXCLUDED.promo_code_id`,
+		id, o.ID, o.UserID)
+	return errors.Wrapf(err, "error recording usage of promo code with id: %d", id)
+}
+
+func (r *PromoRepository) readRestrictions(ctx context.Context, promoCode *PromoCode) error {
+	for _, restriction := range restrictionTables {
+		ids := make([]uint64, 0)
+		err := r.db.Select(ctx, &ids,
+			"SELECT "+restriction.column+" FROM "+restriction.table+" WHERE promo_code_id = $1", promoCode.ID)
+		if err != nil {
+			return errors.Wrapf(err, "error getting restrictions of promo code with id: %d", promoCode.ID)
+		}
+		*restriction.ids(promoCode) = ids
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context, promoCode *PromoCode) (uint64, error)
+	Read(ctx context.Context, id uint64) (*PromoCode, error)
+	ReadByCode(ctx context.Context, code string) (*PromoCode, error)
+	LockById(ctx context.Context, id uint64) (*PromoCode, error)
+	ReadAll(ctx context.Context) ([]*PromoCode, error)
+	Delete(ctx context.Context, id uint64) (bool, error)
+	ReadUsage(ctx context.Context, id, userID uint64) (*Usage, error)
+	CreateUsage(ctx context.Context, id uint64, o *order.Order) error
+}
+
+type OrderRepository interface {
+	ReadByIdEager(ctx context.Context, id uint64) (*order.Order, error)
+	UpdateDiscount(ctx context.Context, orderID uint64, promoCodeID *uint64, discount uint64) error
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type PromoService struct {
+	repository      Repository
+	orderRepository OrderRepository
+	transactor      Transactor
+}
+
+func NewService(repository Repository, orderRepository OrderRepository, transactor Transactor) *PromoService {
+	return &PromoService{
+		repository:      repository,
+		orderRepository: orderRepository,
+		transactor:      transactor,
+	}
+}
+
+func (s *PromoService) Create(c echo.Context, dto *DTO) (uint64, error) {
+	promoCode := dto.ToPromoCode()
+	if promoCode.Code == "" || promoCode.Value == 0 ||
+		(promoCode.Type != TypePercent && promoCode.Type != TypeFixed) ||
+		(promoCode.Type == TypePercent && promoCode.Value > 100) ||
+		(promoCode.StartsAt != nil && promoCode.EndsAt != nil && !promoCode.StartsAt.Before(*promoCode.EndsAt)) {
+		return 0, PromoCodeInvalidErr
+	}
+
+	var id uint64
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		id, err = s.repository.Create(ctx, promoCode)
+		return err
+	})
+
+	return id, err
+}
+
+func (s *PromoService) Read(c echo.Context, id uint64) (*DTO, error) {
+	promoCode, err := s.repository.Read(c.Request().Context(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return promoCode.ToDTO(), nil
+}
+
+func (s *PromoService) ReadAll(c echo.Context) ([]*DTO, error) {
+	promoCodes, err := s.repository.ReadAll(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(promoCodes) == 0 {
+		return nil, PromoCodeNotFoundErr
+	}
+
+	return ToDTOs(promoCodes), nil
+}
+
+func (s *PromoService) Delete(c echo.Context, id uint64) (bool, error) {
+	return s.repository.Delete(c.Request().Context(), id)
+}
+
+// Apply attaches the promo code to the cart if the cart can be discounted with it
+func (s *PromoService) Apply(ctx context.Context, cart *order.Order, code string) error {
+	promoCode, err := s.repository.ReadByCode(ctx, NormalizeCode(code))
+	if err != nil {
+		return err
+	}
+
+	discount, err := s.evaluate(ctx, promoCode, cart)
+	if err != nil {
+		return err
+	}
+
+	return s.orderRepository.UpdateDiscount(ctx, cart.ID, &promoCode.ID, discount)
+}
+
+// Refresh recalculates the discount of the changed cart. The promo code stays attached even if it
+// doesn't give a discount anymore, so it is applied again once the cart matches its conditions
+func (s *PromoService) Refresh(ctx context.Context, cart *order.Order) error {
+	if cart.PromoCodeID == nil {
+		return nil
+	}
+
+	promoCode, err := s.repository.Read(ctx, *cart.PromoCodeID)
+	if errors.Is(err, PromoCodeNotFoundErr) {
+		return s.orderRepository.UpdateDiscount(ctx, cart.ID, nil, 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	discount, err := s.evaluate(ctx, promoCode, cart)
+	if err != nil && !isValidationErr(err) {
+		return err
+	}
+
+	return s.orderRepository.UpdateDiscount(ctx, cart.ID, cart.PromoCodeID, discount)
+}
+
+// Redeem validates the promo code of the order at checkout and records its usage. The promo code
+// is locked until the end of the checkout, so concurrent checkouts can't exceed its usage limits
+func (s *PromoService) Redeem(ctx context.Context, o *order.Order) (uint64, error) {
+	promoCode, err := s.repository.LockById(ctx, *o.PromoCodeID)
+	if errors.Is(err, PromoCodeNotFoundErr) {
+		return 0, &order.PromoCodeErr{Reason: err}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	o, err = s.orderRepository.ReadByIdEager(ctx, o.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	discount, err := s.evaluate(ctx, promoCode, o)
+	if isValidationErr(err) {
+		return 0, &order.PromoCodeErr{Reason: err}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return discount, s.repository.CreateUsage(ctx, promoCode.ID, o)
+}
+
+// evaluate checks whether the order can be discounted with the promo code and returns the discount.
+// Order items must be loaded with their products
+func (s *PromoService) evaluate(ctx context.Context, promoCode *PromoCode, o *order.Order) (uint64, error) {
+	usage, err := s.repository.ReadUsage(ctx, promoCode.ID, o.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = promoCode.Validate(o, usage, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+
+	return promoCode.Discount(o)
+}
+
+func isValidationErr(err error) bool {
+	return errors.Is(err, PromoCodeNotStartedErr) || errors.Is(err, PromoCodeExpiredErr) ||
+		errors.Is(err, PromoCodeExhaustedErr) || errors.Is(err, PromoCodeUserLimitErr) ||
+		errors.Is(err, PromoCodeMinTotalErr) || errors.Is(err, PromoCodeNotApplicableErr)
+}
@@ -0,0 +1,34 @@
+package refund
+
+import "time"
+
+type DTO struct {
+	ID           uint64     `json:"id"`
+	OrderID      uint64     `json:"order_id"`
+	UserID       uint64     `json:"user_id"`
+	Status       string     `json:"status"`
+	Reason       string     `json:"reason"`
+	AdminComment string     `json:"admin_comment,omitempty"`
+	Amount       uint64     `json:"amount"`
+	Currency     string     `json:"currency"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	Items        []*ItemDTO `json:"items"`
+}
+
+type ItemDTO struct {
+	ProductID uint64 `json:"product_id"`
+	Name      string `json:"name,omitempty"`
+	Quantity  uint64 `json:"quantity"`
+	Price     uint64 `json:"price,omitempty"`
+}
+
+// RequestDTO asks to refund the items, without items everything not refunded yet is requested
+type RequestDTO struct {
+	Reason string     `json:"reason"`
+	Items  []*ItemDTO `json:"items"`
+}
+
+type ResolutionDTO struct {
+	Comment string `json:"comment"`
+}
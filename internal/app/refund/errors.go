@@ -0,0 +1,13 @@
+package refund
+
+import "errors"
+
+var (
+	RefundNotFoundErr   = errors.New("заявка на возврат не найдена")
+	RefundNotAllowedErr = errors.New("возврат возможен только для оплаченных заказов")
+	RefundQuantityErr   = errors.New("количество товара к возврату превышает доступное")
+	RefundEmptyErr      = errors.New("в заявке на возврат нет товаров")
+	RefundResolvedErr   = errors.New("заявка на возврат уже рассмотрена")
+	RefundNoReasonErr   = errors.New("необходимо указать причину возврата")
+	RefundNoCommentErr  = errors.New("необходимо указать причину отказа в возврате")
+)
@@ -0,0 +1,192 @@
+package refund
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/payment"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Create(c echo.Context, orderID uint64, dto *RequestDTO, actor *auth.UserData) (*DTO, error)
+	ReadByOrderID(c echo.Context, orderID uint64, actor *auth.UserData) ([]*DTO, error)
+	ReadByStatus(c echo.Context, status string) ([]*DTO, error)
+	Approve(c echo.Context, id uint64, dto *ResolutionDTO) (*DTO, error)
+	Reject(c echo.Context, id uint64, dto *ResolutionDTO) (*DTO, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+
+	if err != nil {
+		return err
+	}
+
+	orderID, err := parseID(c, "ошибка парсинга id заказа", "id заказа должно быть положительным")
+	if err != nil {
+		return err
+	}
+
+	requestDTO := RequestDTO{}
+
+	if err = c.Bind(&requestDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	refundDTO, err := h.service.Create(c, orderID, &requestDTO, userData)
+	if err != nil {
+		return refundError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"refund": refundDTO,
+	})
+}
+
+func (h *Handler) ReadByOrderID(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user", "admin")
+
+	if err != nil {
+		return err
+	}
+
+	orderID, err := parseID(c, "ошибка парсинга id заказа", "id заказа должно быть положительным")
+	if err != nil {
+		return err
+	}
+
+	refundDTOs, err := h.service.ReadByOrderID(c, orderID, userData)
+	if err != nil {
+		return refundError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"refunds": refundDTOs,
+	})
+}
+
+// ReadAll returns refunds of all users, ?status narrows them to the refunds with the given status
+func (h *Handler) ReadAll(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	status := c.QueryParam("status")
+	if status != "" && status != StatusPending && status != StatusApproved && status != StatusRejected {
+		return echo.NewHTTPError(http.StatusBadRequest, "неизвестный статус возврата: "+status)
+	}
+
+	refundDTOs, err := h.service.ReadByStatus(c, status)
+	if err != nil {
+		return refundError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"refunds": refundDTOs,
+	})
+}
+
+func (h *Handler) Approve(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(c, "ошибка парсинга id возврата", "id возврата должно быть положительным")
+	if err != nil {
+		return err
+	}
+
+	resolutionDTO := ResolutionDTO{}
+
+	if err = c.Bind(&resolutionDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	refundDTO, err := h.service.Approve(c, id, &resolutionDTO)
+	if err != nil {
+		return refundError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"refund": refundDTO,
+	})
+}
+
+func (h *Handler) Reject(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(c, "ошибка парсинга id возврата", "id возврата должно быть положительным")
+	if err != nil {
+		return err
+	}
+
+	resolutionDTO := ResolutionDTO{}
+
+	if err = c.Bind(&resolutionDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	refundDTO, err := h.service.Reject(c, id, &resolutionDTO)
+	if err != nil {
+		return refundError(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":   http.StatusOK,
+		"refund": refundDTO,
+	})
+}
+
+func parseID(c echo.Context, parseMessage, positiveMessage string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, parseMessage)
+	}
+	if id <= 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, positiveMessage)
+	}
+	return id, nil
+}
+
+// refundError converts errors of refund service to http responses
+func refundError(err error) error {
+	switch {
+	case errors.Is(err, RefundNotFoundErr), errors.Is(err, order.OrderNotFoundErr):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, order.ForeignOrderErr):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, RefundResolvedErr), errors.Is(err, RefundNotAllowedErr), errors.Is(err, order.TransitionErr),
+		errors.Is(err, payment.RefundExceedsPaymentErr):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, RefundQuantityErr), errors.Is(err, RefundEmptyErr),
+		errors.Is(err, RefundNoReasonErr), errors.Is(err, RefundNoCommentErr):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, "ошибка обработки возврата")
+}
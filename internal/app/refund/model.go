@@ -0,0 +1,80 @@
+package refund
+
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+)
+
+const (
+	StatusPending  = "Рассматривается"
+	StatusApproved = "Одобрен"
+	StatusRejected = "Отклонен"
+)
+
+type Refund struct {
+	ID           uint64     `db:"id"`
+	OrderID      uint64     `db:"order_id"`
+	UserID       uint64     `db:"user_id"`
+	Status       string     `db:"status"`
+	Reason       string     `db:"reason"`
+	AdminComment string     `db:"admin_comment"`
+	Amount       uint64     `db:"amount"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+	ResolvedAt   *time.Time `db:"resolved_at"`
+	Items        []*Item
+}
+
+type Item struct {
+	RefundID    uint64 `db:"refund_id"`
+	ProductID   uint64 `db:"product_id"`
+	ProductName string `db:"product_name"`
+	Quantity    uint64 `db:"quantity"`
+	Price       uint64 `db:"price"`
+}
+
+func (r *Refund) ToDTO() *DTO {
+	return &DTO{
+		ID:           r.ID,
+		OrderID:      r.OrderID,
+		UserID:       r.UserID,
+		Status:       r.Status,
+		Reason:       r.Reason,
+		AdminComment: r.AdminComment,
+		Amount:       r.Amount,
+		Currency:     currency.Base,
+		CreatedAt:    r.CreatedAt,
+		ResolvedAt:   r.ResolvedAt,
+		Items:        ItemsToDTOs(r.Items),
+	}
+}
+
+func (i *Item) ToDTO() *ItemDTO {
+	return &ItemDTO{
+		ProductID: i.ProductID,
+		Name:      i.ProductName,
+		Quantity:  i.Quantity,
+		Price:     i.Price,
+	}
+}
+
+func ToDTOs(refunds []*Refund) []*DTO {
+	var refundDTOs []*DTO
+
+	for _, refund := range refunds {
+		refundDTOs = append(refundDTOs, refund.ToDTO())
+	}
+
+	return refundDTOs
+}
+
+func ItemsToDTOs(items []*Item) []*ItemDTO {
+	var itemDTOs []*ItemDTO
+
+	for _, item := range items {
+		itemDTOs = append(itemDTOs, item.ToDTO())
+	}
+
+	return itemDTOs
+}
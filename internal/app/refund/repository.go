@@ -0,0 +1,209 @@
+package refund
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type RefundRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+// Create inserts the refund with its items, it must be called inside a transaction
+func (r *RefundRepository) Create(ctx context.Context, refund *Refund) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, `
+		INSERT INTO refunds(order_id, user_id, status, reason, amount)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		refund.OrderID, refund.UserID, refund.Status, refund.Reason, refund.Amount).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error creating refund: %v", refund)
+	}
+
+	for _, item := range refund.Items {
+		_, err = r.db.Exec(ctx, `
+			INSERT INTO refund_items(refund_id, order_id, product_id, quantity, price)
+			VALUES ($1, $2, $3, $4, $5)`,
+			id, refund.OrderID, item.ProductID, item.Quantity, item.Price)
+		if err != nil {
+			return 0, errors.Wrapf(err, "error creating refund item: %v", item)
+		}
+	}
+
+	return id, nil
+}
+
+func (r *RefundRepository) Read(ctx context.Context, id uint64) (*Refund, error) {
+	var refund Refund
+	err := r.db.Get(ctx, &refund, `
+		SELECT id, order_id, user_id, status, reason, admin_comment, amount, created_at, updated_at, resolved_at
+		FROM refunds
+		WHERE id = $1`, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, RefundNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting refund with id: %d", id)
+	}
+
+	refund.Items, err = r.readItems(ctx, id)
+	return &refund, err
+}
+
+// LockById reads the refund and locks it until the end of the transaction
+func (r *RefundRepository) LockById(ctx context.Context, id uint64) (*Refund, error) {
+	var refund Refund
+	err := r.db.Get(ctx, &refund, `
+		SELECT id, order_id, user_id, status, reason, admin_comment, amount, created_at, updated_at, resolved_at
+		FROM refunds
+		WHERE id = $1
+		FOR UPDATE`, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, RefundNotFoundErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error locking refund with id: %d", id)
+	}
+
+	refund.Items, err = r.readItems(ctx, id)
+	return &refund, err
+}
+
+func (r *RefundRepository) ReadByOrderID(ctx context.Context, orderID uint64) ([]*Refund, error) {
+	refunds := make([]*Refund, 0)
+	err := r.db.Select(ctx, &refunds, `
+		SELECT id, order_id, user_id, status, reason, admin_comment, amount, created_at, updated_at, resolved_at
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY created_at DESC`, orderID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting refunds of order with id: %d", orderID)
+	}
+
+	return refunds, r.fillItems(ctx, refunds)
+}
+
+// ReadByStatus returns refunds with the status or all refunds if status is empty
+func (r *RefundRepository) ReadByStatus(ctx context.Context, status string) ([]*Refund, error) {
+	refunds := make([]*Refund, 0)
+	err := r.db.Select(ctx, &refunds, `
+		SELECT id, order_id, user_id, status, reason, admin_comment, amount, created_at, updated_at, resolved_at
+		FROM refunds
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC`, status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting refunds with status: %s", status)
+	}
+
+	return refunds, r.fillItems(ctx, refunds)
+}
+
+// PendingQuantities returns quantities of the order products which are already requested to refund
+func (r *RefundRepository) PendingQuantities(ctx context.Context, orderID uint64) (map[uint64]uint64, error) {
+	items := make([]*Item, 0)
+	err := r.db.Select(ctx, &items, `
+		SELECT refund_items.product_id, SUM(refund_items.quantity) as quantity
+		FROM refund_items
+			JOIN refunds ON refunds.id = refund_items.refund_id
+		WHERE refunds.order_id = $1 AND refunds.status = $2
+		GROUP BY refund_items.product_id`, orderID, StatusPending)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting pending refund quantities of order with id: %d", orderID)
+	}
+
+	quantities := make(map[uint64]uint64, len(items))
+	for _, item := range items {
+		quantities[item.ProductID] = item.Quantity
+	}
+
+	return quantities, nil
+}
+
+func (r *RefundRepository) Resolve(ctx context.Context, refund *Refund) (bool, error) {
+	now := time.Now().UTC()
+	refund.UpdatedAt = now
+	refund.ResolvedAt = &now
+	result, err := r.db.Exec(ctx,
+		"UPDATE refunds SET status = $1, admin_comment = $2, updated_at = $3, resolved_at = $3 WHERE id = $4",
+		refund.Status, refund.AdminComment, now, refund.ID)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error resolving refund: %v", refund)
+}
+
+// Apply marks the refund items as refunded in the order and adds the refund amount to the order.
+// Items are returned to the stock only when restock is set, delivered items are just revoked
+func (r *RefundRepository) Apply(ctx context.Context, refund *Refund, restock bool) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE order_items
+		SET refunded_quantity = order_items.refunded_quantity + refund_items.quantity, updated_at = NOW()
+		FROM refund_items
+		WHERE refund_items.refund_id = $1
+		  AND order_items.order_id = refund_items.order_id AND order_items.product_id = refund_items.product_id`, refund.ID)
+	if err != nil {
+		return errors.Wrapf(err, "error refunding items of refund: %v", refund)
+	}
+
+	_, err = r.db.Exec(ctx, "UPDATE orders SET refunded_total = refunded_total + $1, updated_at = NOW() WHERE id = $2",
+		refund.Amount, refund.OrderID)
+	if err != nil {
+		return errors.Wrapf(err, "error adding refund to order: %v", refund)
+	}
+
+	if !restock {
+		return nil
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE products
+		SET stock = products.stock + refund_items.quantity
+		FROM refund_items
+		WHERE refund_items.refund_id = $1 AND products.id = refund_items.product_id`, refund.ID)
+	return errors.Wrapf(err, "error restocking items of refund: %v", refund)
+}
+
+// IsOrderFullyRefunded reports whether every item of the order was refunded
+func (r *RefundRepository) IsOrderFullyRefunded(ctx context.Context, orderID uint64) (bool, error) {
+	var isRefunded bool
+	err := r.db.Get(ctx, &isRefunded,
+		"SELECT NOT EXISTS(SELECT 1 FROM order_items WHERE order_id = $1 AND refunded_quantity < quantity)", orderID)
+	return isRefunded, errors.Wrapf(err, "error checking refunds of order with id: %d", orderID)
+}
+
+func (r *RefundRepository) readItems(ctx context.Context, refundID uint64) ([]*Item, error) {
+	items := make([]*Item, 0)
+	err := r.db.Select(ctx, &items, `
+		SELECT refund_items.refund_id, refund_items.product_id, refund_items.quantity, refund_items.price,
+		       COALESCE(order_items.product_name, products.name) as product_name
+		FROM refund_items
+			JOIN order_items ON order_items.order_id = refund_items.order_id AND order_items.product_id = refund_items.product_id
+			JOIN products ON products.id = refund_items.product_id
+		WHERE refund_items.refund_id = $1`, refundID)
+	return items, errors.Wrapf(err, "error getting items of refund with id: %d", refundID)
+}
+
+func (r *RefundRepository) fillItems(ctx context.Context, refunds []*Refund) error {
+	for _, refund := range refunds {
+		items, err := r.readItems(ctx, refund.ID)
+		if err != nil {
+			return err
+		}
+		refund.Items = items
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package refund
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context, refund *Refund) (uint64, error)
+	Read(ctx context.Context, id uint64) (*Refund, error)
+	LockById(ctx context.Context, id uint64) (*Refund, error)
+	ReadByOrderID(ctx context.Context, orderID uint64) ([]*Refund, error)
+	ReadByStatus(ctx context.Context, status string) ([]*Refund, error)
+	PendingQuantities(ctx context.Context, orderID uint64) (map[uint64]uint64, error)
+	Resolve(ctx context.Context, refund *Refund) (bool, error)
+	Apply(ctx context.Context, refund *Refund, restock bool) error
+	IsOrderFullyRefunded(ctx context.Context, orderID uint64) (bool, error)
+}
+
+type OrderRepository interface {
+	LockById(ctx context.Context, id uint64) (*order.Order, error)
+	ReadByIdEager(ctx context.Context, id uint64) (*order.Order, error)
+}
+
+type OrderService interface {
+	ChangeStatus(ctx context.Context, id uint64, status string, actor *auth.UserData, comment string) (*order.DTO, error)
+	NotifyCustomer(ctx context.Context, userID uint64, subject, message string)
+}
+
+// PaymentRefunder returns money of the approved refunds to the customer
+type PaymentRefunder interface {
+	RefundOrder(ctx context.Context, orderID uint64, amount uint64) error
+}
+
+// systemActor refunds orders on behalf of the store once their refunds are approved
+var systemActor = &auth.UserData{Role: order.SystemRole}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type RefundService struct {
+	repository      Repository
+	orderRepository OrderRepository
+	orderService    OrderService
+	payments        PaymentRefunder
+	transactor      Transactor
+}
+
+func NewService(repository Repository, orderRepository OrderRepository, orderService OrderService, payments PaymentRefunder, transactor Transactor) *RefundService {
+	return &RefundService{
+		repository:      repository,
+		orderRepository: orderRepository,
+		orderService:    orderService,
+		payments:        payments,
+		transactor:      transactor,
+	}
+}
+
+// Create requests a refund of the paid order items. Requested quantities can't exceed the quantities
+// which are neither refunded nor requested to refund yet, amounts are taken from the prices fixed in the order
+func (s *RefundService) Create(c echo.Context, orderID uint64, dto *RequestDTO, actor *auth.UserData) (*DTO, error) {
+	if dto.Reason == "" {
+		return nil, RefundNoReasonErr
+	}
+
+	refund := &Refund{
+		OrderID: orderID,
+		UserID:  actor.ID,
+		Status:  StatusPending,
+		Reason:  dto.Reason,
+	}
+
+	var o *order.Order
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		o, err = s.orderRepository.LockById(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if o.UserID != actor.ID {
+			return order.ForeignOrderErr
+		}
+		if o.Status != order.StatusPaid && o.Status != order.StatusDelivered {
+			return RefundNotAllowedErr
+		}
+
+		o, err = s.orderRepository.ReadByIdEager(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		pending, err := s.repository.PendingQuantities(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		refund.Items, err = collectItems(o, pending, dto.Items)
+		if err != nil {
+			return err
+		}
+
+		refund.Amount = refundAmount(o, pending, refund.Items)
+
+		refund.ID, err = s.repository.Create(ctx, refund)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.orderService.NotifyCustomer(c.Request().Context(), actor.ID, fmt.Sprintf("Заявка на возврат №%d", refund.ID),
+		fmt.Sprintf("Здравствуйте, мы получили вашу заявку на возврат %s по заказу №%d и рассмотрим ее в ближайшее время.",
+			o.Format(refund.Amount), refund.OrderID))
+
+	return s.Read(c, refund.ID)
+}
+
+func (s *RefundService) Read(c echo.Context, id uint64) (*DTO, error) {
+	refund, err := s.repository.Read(c.Request().Context(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return refund.ToDTO(), nil
+}
+
+// ReadByOrderID returns refunds of the order, users can see only refunds of their own orders
+func (s *RefundService) ReadByOrderID(c echo.Context, orderID uint64, actor *auth.UserData) ([]*DTO, error) {
+	if actor.Role == "user" {
+		o, err := s.orderRepository.ReadByIdEager(c.Request().Context(), orderID)
+		if err != nil {
+			return nil, err
+		}
+		if o.UserID != actor.ID {
+			return nil, order.ForeignOrderErr
+		}
+	}
+
+	refunds, err := s.repository.ReadByOrderID(c.Request().Context(), orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(refunds) == 0 {
+		return nil, RefundNotFoundErr
+	}
+
+	return ToDTOs(refunds), nil
+}
+
+func (s *RefundService) ReadByStatus(c echo.Context, status string) ([]*DTO, error) {
+	refunds, err := s.repository.ReadByStatus(c.Request().Context(), status)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(refunds) == 0 {
+		return nil, RefundNotFoundErr
+	}
+
+	return ToDTOs(refunds), nil
+}
+
+// Approve refunds the requested items. Items of paid orders go back to the stock while delivered items
+// are revoked. The money is returned through the payment provider and the order becomes refunded
+// once all of its items are refunded
+func (s *RefundService) Approve(c echo.Context, id uint64, dto *ResolutionDTO) (*DTO, error) {
+	var refund *Refund
+	var o *order.Order
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		refund, err = s.repository.LockById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if refund.Status != StatusPending {
+			return RefundResolvedErr
+		}
+
+		o, err = s.orderRepository.LockById(ctx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		if o.Status != order.StatusPaid && o.Status != order.StatusDelivered {
+			return RefundNotAllowedErr
+		}
+
+		if err = s.repository.Apply(ctx, refund, o.Status == order.StatusPaid); err != nil {
+			return err
+		}
+
+		if err = s.payments.RefundOrder(ctx, refund.OrderID, refund.Amount); err != nil {
+			return err
+		}
+
+		refund.Status = StatusApproved
+		refund.AdminComment = dto.Comment
+		if _, err = s.repository.Resolve(ctx, refund); err != nil {
+			return err
+		}
+
+		isRefunded, err := s.repository.IsOrderFullyRefunded(ctx, refund.OrderID)
+		if err != nil || !isRefunded {
+			return err
+		}
+
+		_, err = s.orderService.ChangeStatus(ctx, refund.OrderID, order.StatusRefunded, systemActor,
+			fmt.Sprintf("заявка на возврат №%d", refund.ID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Здравствуйте, ваша заявка на возврат №%d по заказу №%d одобрена. Сумма возврата: %s.",
+		refund.ID, refund.OrderID, o.Format(refund.Amount))
+	if refund.AdminComment != "" {
+		message += fmt.Sprintf("\nКомментарий: %s", refund.AdminComment)
+	}
+	s.orderService.NotifyCustomer(c.Request().Context(), refund.UserID, fmt.Sprintf("Возврат №%d одобрен", refund.ID), message)
+
+	return s.Read(c, refund.ID)
+}
+
+func (s *RefundService) Reject(c echo.Context, id uint64, dto *ResolutionDTO) (*DTO, error) {
+	if dto.Comment == "" {
+		return nil, RefundNoCommentErr
+	}
+
+	var refund *Refund
+
+	err := s.transactor.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		var err error
+		refund, err = s.repository.LockById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if refund.Status != StatusPending {
+			return RefundResolvedErr
+		}
+
+		refund.Status = StatusRejected
+		refund.AdminComment = dto.Comment
+		_, err = s.repository.Resolve(ctx, refund)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.orderService.NotifyCustomer(c.Request().Context(), refund.UserID, fmt.Sprintf("Возврат №%d отклонен", refund.ID),
+		fmt.Sprintf("Здравствуйте, ваша заявка на возврат №%d по заказу №%d отклонена.\nПричина: %s",
+			refund.ID, refund.OrderID, refund.AdminComment))
+
+	return s.Read(c, refund.ID)
+}
+
+// refundAmount returns the money paid for the items. Order discount, bundle savings and taxes added to the prices
+// are spread over the items in proportion to their prices, the refund of everything left in the order returns
+// all the money not refunded yet
+func refundAmount(o *order.Order, pending map[uint64]uint64, items []*Item) uint64 {
+	var amount, quantity, remaining uint64
+	for _, item := range items {
+		amount += item.Quantity * item.Price
+		quantity += item.Quantity
+	}
+	subtotal := o.Subtotal()
+	if subtotal == 0 {
+		return amount
+	}
+
+	for _, orderItem := range o.OrderItems {
+		remaining += uint64(orderItem.Quantity - orderItem.Refunded)
+	}
+	if len(pending) == 0 && quantity == remaining {
+		if o.Refunded >= o.Payable() {
+			return 0
+		}
+		return o.Payable() - o.Refunded
+	}
+
+	return amount * o.Payable() / subtotal
+}
+
+// collectItems builds refund items from the requested ones or from everything refundable if nothing is requested
+func collectItems(o *order.Order, pending map[uint64]uint64, requested []*ItemDTO) ([]*Item, error) {
+	refundable := make(map[uint64]uint64, len(o.OrderItems))
+	prices := make(map[uint64]uint64, len(o.OrderItems))
+	for _, orderItem := range o.OrderItems {
+		available := uint64(orderItem.Quantity - orderItem.Refunded)
+		if available > pending[orderItem.Product.ID] {
+			refundable[orderItem.Product.ID] = available - pending[orderItem.Product.ID]
+		}
+		prices[orderItem.Product.ID] = orderItem.Price
+	}
+
+	items := make([]*Item, 0)
+
+	if len(requested) == 0 {
+		for productID, quantity := range refundable {
+			items = append(items, &Item{ProductID: productID, Quantity: quantity, Price: prices[productID]})
+		}
+	} else {
+		seen := make(map[uint64]bool, len(requested))
+		for _, item := range requested {
+			if item.Quantity == 0 || seen[item.ProductID] || item.Quantity > refundable[item.ProductID] {
+				return nil, RefundQuantityErr
+			}
+			seen[item.ProductID] = true
+			items = append(items, &Item{ProductID: item.ProductID, Quantity: item.Quantity, Price: prices[item.ProductID]})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, RefundEmptyErr
+	}
+
+	return items, nil
+}
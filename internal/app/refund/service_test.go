@@ -0,0 +1,95 @@
+package refund
+
+import (
+	"testing"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+)
+
+func orderItems(items ...*orderItem.OrderItem) []*orderItem.OrderItem {
+	return items
+}
+
+func TestRefundAmount(t *testing.T) {
+	// two items of 1000 and one of 2000
+	items := func(refundedA int) []*orderItem.OrderItem {
+		return orderItems(
+			&orderItem.OrderItem{Quantity: 2, Price: 1000, Refunded: refundedA},
+			&orderItem.OrderItem{Quantity: 1, Price: 2000},
+		)
+	}
+
+	tests := []struct {
+		name    string
+		order   *order.Order
+		pending map[uint64]uint64
+		items   []*Item
+		want    uint64
+	}{
+		{
+			name:  "no discount",
+			order: &order.Order{Total: 4000, TaxInclusive: true, OrderItems: items(0)},
+			items: []*Item{{Quantity: 1, Price: 1000}},
+			want:  1000,
+		},
+		{
+			name:  "promo discount is spread over items",
+			order: &order.Order{Total: 4000, Discount: 400, TaxInclusive: true, OrderItems: items(0)},
+			items: []*Item{{Quantity: 1, Price: 1000}},
+			want:  900,
+		},
+		{
+			name:  "bundle savings are spread over items",
+			order: &order.Order{Total: 3500, TaxInclusive: true, OrderItems: items(0)},
+			items: []*Item{{Quantity: 1, Price: 2000}},
+			want:  1750,
+		},
+		{
+			name:  "taxes added to prices are refunded",
+			order: &order.Order{Total: 4000, TaxTotal: 800, OrderItems: items(0)},
+			items: []*Item{{Quantity: 1, Price: 1000}},
+			want:  1200,
+		},
+		{
+			name:  "last refund returns the rest",
+			order: &order.Order{Total: 4000, Discount: 400, Refunded: 800, TaxInclusive: true, OrderItems: items(1)},
+			items: []*Item{{Quantity: 1, Price: 1000}, {Quantity: 1, Price: 2000}},
+			want:  2800,
+		},
+		{
+			name:    "refund with pending refunds isn't the last one",
+			order:   &order.Order{Total: 4000, Discount: 400, Refunded: 800, TaxInclusive: true, OrderItems: items(1)},
+			pending: map[uint64]uint64{1: 1},
+			items:   []*Item{{Quantity: 1, Price: 1000}, {Quantity: 1, Price: 2000}},
+			want:    2700,
+		},
+		{
+			name:    "pending refunds keep the proportional amount",
+			order:   &order.Order{Total: 4000, Discount: 400, TaxInclusive: true, OrderItems: items(0)},
+			pending: map[uint64]uint64{1: 1},
+			items:   []*Item{{Quantity: 1, Price: 2000}},
+			want:    1800,
+		},
+		{
+			name:  "nothing left to refund",
+			order: &order.Order{Total: 4000, Discount: 400, Refunded: 3600, TaxInclusive: true, OrderItems: items(1)},
+			items: []*Item{{Quantity: 1, Price: 1000}, {Quantity: 1, Price: 2000}},
+			want:  0,
+		},
+		{
+			name:  "order without items",
+			order: &order.Order{TaxInclusive: true},
+			items: []*Item{{Quantity: 1, Price: 1000}},
+			want:  1000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refundAmount(tt.order, tt.pending, tt.items); got != tt.want {
+				t.Errorf("refundAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package reminder
+
+import "errors"
+
+var (
+	InvalidUnsubscribeTokenErr = errors.New("неверная ссылка для отписки")
+	UserNotFoundErr            = errors.New("пользователь не найден")
+)
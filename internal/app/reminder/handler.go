@@ -0,0 +1,60 @@
+package reminder
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Unsubscribe(c echo.Context, token string) error
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+const confirmPage = `<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Отписка от напоминаний</title></head>
+<body>
+<form method="post">
+<p>Вы действительно хотите отписаться от напоминаний о корзине?</p>
+<input type="hidden" name="token" value="%s">
+<button type="submit">Отписаться</button>
+</form>
+</body>
+</html>`
+
+// ConfirmUnsubscribe is opened from the link in reminder emails. It only asks to confirm the unsubscription,
+// as mail clients and link scanners open links without the user
+func (h *Handler) ConfirmUnsubscribe(c echo.Context) error {
+	return c.HTML(http.StatusOK, fmt.Sprintf(confirmPage, html.EscapeString(c.QueryParam("token"))))
+}
+
+// Unsubscribe is submitted from the confirmation page, so it doesn't need the user to be logged in
+func (h *Handler) Unsubscribe(c echo.Context) error {
+	err := h.service.Unsubscribe(c, c.FormValue("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, InvalidUnsubscribeTokenErr):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, UserNotFoundErr):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "ошибка отписки от напоминаний")
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "вы отписались от напоминаний о корзине",
+	})
+}
@@ -0,0 +1,11 @@
+package reminder
+
+import "time"
+
+// Cart is a cart of the user which hasn't changed for a while
+type Cart struct {
+	OrderID      uint64    `db:"order_id"`
+	UserID       uint64    `db:"user_id"`
+	Email        string    `db:"email"`
+	LastActivity time.Time `db:"last_activity"`
+}
@@ -0,0 +1,68 @@
+package reminder
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type ReminderRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// ReadIdleCarts returns carts with items which haven't changed for longer than idle and whose owners
+// haven't unsubscribed. Carts already reminded about since their last change or within the window are skipped.
+// Activity of carts is stored in UTC, so it's compared with reminders as absolute moments
+func (r *ReminderRepository) ReadIdleCarts(ctx context.Context, idle, window time.Duration) ([]*Cart, error) {
+	carts := make([]*Cart, 0)
+	err := r.db.Select(ctx, &carts, `
+		SELECT orders.id as order_id, orders.user_id, users.email, activity.last_activity
+		FROM orders
+			JOIN users ON users.id = orders.user_id
+			JOIN LATERAL (
+				SELECT GREATEST(orders.updated_at, MAX(order_items.updated_at)) AT TIME ZONE 'UTC' as last_activity
+				FROM order_items
+				WHERE order_items.order_id = orders.id
+				HAVING COUNT(*) > 0
+			) activity ON TRUE
+		WHERE orders.is_arranged = FALSE AND orders.cart_name IS NULL AND users.cart_reminders
+			AND activity.last_activity < NOW() - make_interval(secs => $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM cart_reminders
+				WHERE cart_reminders.order_id = orders.id
+					AND (cart_reminders.sent_at > activity.last_activity
+						OR cart_reminders.sent_at > NOW() - make_interval(secs => $2))
+			)
+		ORDER BY orders.id`, idle.Seconds(), window.Seconds())
+	return carts, errors.Wrap(err, "error reading idle carts")
+}
+
+// Create remembers the reminder sent about the cart
+func (r *ReminderRepository) Create(ctx context.Context, cart *Cart) error {
+	_, err := r.db.Exec(ctx, "INSERT INTO cart_reminders(order_id, user_id) VALUES ($1, $2)", cart.OrderID, cart.UserID)
+	return errors.Wrapf(err, "error creating reminder about cart with id: %d", cart.OrderID)
+}
+
+func (r *ReminderRepository) Unsubscribe(ctx context.Context, userID uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "UPDATE users SET cart_reminders = FALSE WHERE id = $1", userID)
+	if err != nil {
+		return false, errors.Wrapf(err, "error unsubscribing user with id: %d from cart reminders", userID)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,106 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/config"
+	"github.com/Mickey327/rcsp-backend/internal/app/mail"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/signer"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	ReadIdleCarts(ctx context.Context, idle, window time.Duration) ([]*Cart, error)
+	Create(ctx context.Context, cart *Cart) error
+	Unsubscribe(ctx context.Context, userID uint64) (bool, error)
+}
+
+type OrderRepository interface {
+	ReadByIdEager(ctx context.Context, id uint64) (*order.Order, error)
+}
+
+// ReminderService reminds users about carts they left without arranging an order
+type ReminderService struct {
+	repository      Repository
+	orderRepository OrderRepository
+	signer          *signer.Signer
+	idle            time.Duration
+	window          time.Duration
+}
+
+// NewService creates the service reminding about carts idle for longer than idle,
+// a cart is reminded about at most once per window
+func NewService(repository Repository, orderRepository OrderRepository, secret string, idle, window time.Duration) *ReminderService {
+	return &ReminderService{
+		repository:      repository,
+		orderRepository: orderRepository,
+		signer:          signer.New(secret, "cart-reminders:"),
+		idle:            idle,
+		window:          window,
+	}
+}
+
+// SendReminders emails owners of idle carts a summary of their items. The reminder is recorded before
+// it's sent, so a cart is never reminded about twice even if sending fails
+func (s *ReminderService) SendReminders(ctx context.Context) error {
+	carts, err := s.repository.ReadIdleCarts(ctx, s.idle, s.window)
+	if err != nil {
+		return err
+	}
+
+	for _, cart := range carts {
+		o, err := s.orderRepository.ReadByIdEager(ctx, cart.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.repository.Create(ctx, cart); err != nil {
+			return err
+		}
+
+		m := mail.New(config.GetConfig().Email, cart.Email, "В вашей корзине остались товары", s.message(cart, o))
+		m.SendMail()
+	}
+
+	if len(carts) > 0 {
+		log.Printf("sent %d abandoned cart reminders", len(carts))
+	}
+	return nil
+}
+
+// Unsubscribe turns reminders off for the user the unsubscribe link was sent to
+func (s *ReminderService) Unsubscribe(c echo.Context, token string) error {
+	userID, ok := s.signer.Verify(token)
+	if !ok {
+		return InvalidUnsubscribeTokenErr
+	}
+
+	isUnsubscribed, err := s.repository.Unsubscribe(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	if !isUnsubscribed {
+		return UserNotFoundErr
+	}
+
+	return nil
+}
+
+func (s *ReminderService) message(cart *Cart, o *order.Order) string {
+	cfg := config.GetConfig()
+	message := "Здравствуйте, вы оставили в корзине товары:\n"
+	for _, item := range o.OrderItems {
+		message += fmt.Sprintf("%s — %d шт. по %s\n", item.ProductName, item.Quantity, o.Format(item.Price))
+	}
+	message += fmt.Sprintf("Итого: %s\n", o.Format(o.Payable()))
+	message += fmt.Sprintf("Оформить заказ можете по ссылке: %s/cart\n", cfg.OuterClientAddress)
+	message += fmt.Sprintf("Чтобы больше не получать напоминания о корзине, перейдите по ссылке: %s/api/cart/reminders/unsubscribe?token=%s",
+		cfg.OuterApiAddress, url.QueryEscape(s.signer.Token(cart.UserID)))
+	return message
+}
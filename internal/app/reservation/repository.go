@@ -0,0 +1,82 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type ReservationRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// LockProducts locks products of the cart until the end of the transaction. Products are locked
+// in the same order as at checkout to avoid deadlocks
+func (r *ReservationRepository) LockProducts(ctx context.Context, orderID uint64) error {
+	_, err := r.db.Exec(ctx, `
+		SELECT 1
+		FROM products
+		WHERE id IN (SELECT product_id FROM order_items WHERE order_id = $1)
+		ORDER BY id
+		FOR UPDATE`, orderID)
+	return errors.Wrapf(err, "error locking products of cart with id: %d", orderID)
+}
+
+// Reserve makes reservations of the cart match its items and extends them for ttl. Items are reserved
+// up to the stock left by other carts, reservations of the items removed from the cart are released
+func (r *ReservationRepository) Reserve(ctx context.Context, orderID uint64, ttl time.Duration) error {
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM stock_reservations
+		WHERE order_id = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM order_items
+				WHERE order_items.order_id = stock_reservations.order_id AND order_items.product_id = stock_reservations.product_id
+			)`, orderID)
+	if err != nil {
+		return errors.Wrapf(err, "error releasing removed items of cart with id: %d", orderID)
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO stock_reservations(order_id, product_id, quantity, expires_at)
+		SELECT order_id, product_id, LEAST(quantity, available_stock(product_id, order_id)),
+		       NOW() + make_interval(secs => $2)
+		FROM order_items
+		WHERE order_id = $1
+		ON CONFLICT (order_id, product_id) DO UPDATE
+		SET quantity = EXCLUDED.quantity, expires_at = EXCLUDED.expires_at`, orderID, ttl.Seconds())
+	return errors.Wrapf(err, "error reserving items of cart with id: %d", orderID)
+}
+
+// Available returns the stock of the product which isn't reserved by carts other than the given one
+func (r *ReservationRepository) Available(ctx context.Context, productID, orderID uint64) (uint64, error) {
+	var available uint64
+	err := r.db.ExecQueryRow(ctx, "SELECT COALESCE(available_stock($1, $2), 0)", productID, orderID).Scan(&available)
+	return available, errors.Wrapf(err, "error reading available stock of product with id: %d", productID)
+}
+
+func (r *ReservationRepository) Release(ctx context.Context, orderID uint64) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM stock_reservations WHERE order_id = $1", orderID)
+	return errors.Wrapf(err, "error releasing reservations of cart with id: %d", orderID)
+}
+
+// DeleteExpired deletes reservations which have expired and returns their number
+func (r *ReservationRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM stock_reservations WHERE expires_at <= NOW()")
+	return result.RowsAffected(), errors.Wrap(err, "error deleting expired stock reservations")
+}
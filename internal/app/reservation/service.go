@@ -0,0 +1,69 @@
+package reservation
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type Repository interface {
+	LockProducts(ctx context.Context, orderID uint64) error
+	Reserve(ctx context.Context, orderID uint64, ttl time.Duration) error
+	Available(ctx context.Context, productID, orderID uint64) (uint64, error)
+	Release(ctx context.Context, orderID uint64) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ReservationService holds stock for the items of carts for ttl since the last activity in the cart,
+// so the stock shown to customers doesn't include what others are about to buy
+type ReservationService struct {
+	repository Repository
+	transactor Transactor
+	ttl        time.Duration
+}
+
+func NewService(repository Repository, transactor Transactor, ttl time.Duration) *ReservationService {
+	return &ReservationService{
+		repository: repository,
+		transactor: transactor,
+		ttl:        ttl,
+	}
+}
+
+// Reserve reserves the items of the cart or extends the reservations made before. Products of the cart
+// are locked first, so carts reserving the same products wait for each other instead of taking the same stock
+func (s *ReservationService) Reserve(ctx context.Context, orderID uint64) error {
+	return s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.LockProducts(ctx, orderID); err != nil {
+			return err
+		}
+		return s.repository.Reserve(ctx, orderID, s.ttl)
+	})
+}
+
+// Available returns how much of the product the cart can take without touching reservations of other carts
+func (s *ReservationService) Available(ctx context.Context, productID, orderID uint64) (uint64, error) {
+	return s.repository.Available(ctx, productID, orderID)
+}
+
+// Release drops reservations of the cart, it's done at checkout when the stock is taken for real
+func (s *ReservationService) Release(ctx context.Context, orderID uint64) error {
+	return s.repository.Release(ctx, orderID)
+}
+
+// Sweep deletes expired reservations, they don't hold the stock anymore but stay until swept
+func (s *ReservationService) Sweep(ctx context.Context) error {
+	count, err := s.repository.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		log.Printf("released %d expired stock reservations", count)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package sale
+
+import "time"
+
+type DTO struct {
+	ID         uint64    `json:"id,omitempty"`
+	Name       string    `json:"name"`
+	Percent    uint64    `json:"percent"`
+	ProductID  *uint64   `json:"product_id,omitempty"`
+	CategoryID *uint64   `json:"category_id,omitempty"`
+	CompanyID  *uint64   `json:"company_id,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+}
+
+func (d *DTO) ToSale() *Sale {
+	return &Sale{
+		ID:         d.ID,
+		Name:       d.Name,
+		Percent:    d.Percent,
+		ProductID:  d.ProductID,
+		CategoryID: d.CategoryID,
+		CompanyID:  d.CompanyID,
+		StartsAt:   d.StartsAt.UTC(),
+		EndsAt:     d.EndsAt.UTC(),
+	}
+}
+
+// IsValid reports whether the sale discounts exactly one product, category or company for a non-empty period
+func (d *DTO) IsValid() bool {
+	targets := 0
+	for _, id := range []*uint64{d.ProductID, d.CategoryID, d.CompanyID} {
+		if id != nil {
+			targets++
+		}
+	}
+	return d.Name != "" && d.Percent > 0 && d.Percent < 100 && targets == 1 && d.StartsAt.Before(d.EndsAt)
+}
@@ -0,0 +1,8 @@
+package sale
+
+import "errors"
+
+var (
+	SaleNotFoundErr       = errors.New("распродажа не найдена")
+	SaleTargetNotFoundErr = errors.New("товар, категория или компания распродажи не найдены")
+)
@@ -0,0 +1,172 @@
+package sale
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Create(c echo.Context, saleDTO *DTO) (uint64, error)
+	Read(c echo.Context, id uint64) (*DTO, error)
+	ReadNotEnded(c echo.Context) ([]*DTO, error)
+	ReadAll(c echo.Context) ([]*DTO, error)
+	Update(c echo.Context, saleDTO *DTO) (bool, error)
+	Delete(c echo.Context, id uint64) (bool, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	saleDTO := DTO{}
+
+	if err = c.Bind(&saleDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	if !saleDTO.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+
+	id, err := h.service.Create(c, &saleDTO)
+	if err != nil {
+		if errors.Is(err, SaleTargetNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка создания распродажи")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"id":      id,
+		"message": "распродажа была успешно создана",
+	})
+}
+
+func (h *Handler) Read(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id распродажи")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id распродажи должно быть положительным")
+	}
+
+	saleDTO, err := h.service.Read(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, SaleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code": http.StatusOK,
+		"sale": saleDTO,
+	})
+}
+
+// ReadNotEnded returns running and upcoming sales to everyone
+func (h *Handler) ReadNotEnded(c echo.Context) error {
+	saleDTOs, err := h.service.ReadNotEnded(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, SaleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"sales": saleDTOs,
+	})
+}
+
+// ReadAll returns every sale including the ended ones to admins
+func (h *Handler) ReadAll(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	saleDTOs, err := h.service.ReadAll(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, SaleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"sales": saleDTOs,
+	})
+}
+
+func (h *Handler) Update(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	saleDTO := DTO{}
+
+	if err = c.Bind(&saleDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	if saleDTO.ID <= 0 || !saleDTO.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+
+	isUpdated, err := h.service.Update(c, &saleDTO)
+	if err != nil {
+		if errors.Is(err, SaleTargetNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка обновления распродажи")
+	}
+
+	if !isUpdated {
+		return echo.NewHTTPError(http.StatusNotFound, SaleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "распродажа была успешно обновлена",
+	})
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id распродажи")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id распродажи должно быть положительным")
+	}
+
+	isDeleted, err := h.service.Delete(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isDeleted {
+		return echo.NewHTTPError(http.StatusNotFound, SaleNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "распродажа была успешно удалена",
+	})
+}
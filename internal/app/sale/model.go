@@ -0,0 +1,40 @@
+package sale
+
+import "time"
+
+// Sale discounts the product, or every product of the category or of the company, while it runs
+type Sale struct {
+	ID         uint64    `db:"id"`
+	Name       string    `db:"name"`
+	Percent    uint64    `db:"percent"`
+	ProductID  *uint64   `db:"product_id"`
+	CategoryID *uint64   `db:"category_id"`
+	CompanyID  *uint64   `db:"company_id"`
+	StartsAt   time.Time `db:"starts_at"`
+	EndsAt     time.Time `db:"ends_at"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+func (s *Sale) ToDTO() *DTO {
+	return &DTO{
+		ID:         s.ID,
+		Name:       s.Name,
+		Percent:    s.Percent,
+		ProductID:  s.ProductID,
+		CategoryID: s.CategoryID,
+		CompanyID:  s.CompanyID,
+		StartsAt:   s.StartsAt,
+		EndsAt:     s.EndsAt,
+	}
+}
+
+func ToDTOs(sales []*Sale) []*DTO {
+	var saleDTOs []*DTO
+
+	for _, sale := range sales {
+		saleDTOs = append(saleDTOs, sale.ToDTO())
+	}
+
+	return saleDTOs
+}
@@ -0,0 +1,90 @@
+package sale
+
+import (
+	"context"
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type SaleRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *SaleRepository {
+	return &SaleRepository{db: db}
+}
+
+func (r *SaleRepository) Create(ctx context.Context, sale *Sale) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, `
+		INSERT INTO sales(name, percent, product_id, category_id, company_id, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		sale.Name, sale.Percent, sale.ProductID, sale.CategoryID, sale.CompanyID, sale.StartsAt, sale.EndsAt).Scan(&id)
+	if repository.IsForeignKeyViolation(err) {
+		return 0, SaleTargetNotFoundErr
+	}
+	return id, errors.Wrapf(err, "error creating sale: %v", sale)
+}
+
+func (r *SaleRepository) Read(ctx context.Context, id uint64) (*Sale, error) {
+	var s Sale
+	err := r.db.Get(ctx, &s, `
+		SELECT id, name, percent, product_id, category_id, company_id, starts_at, ends_at, created_at, updated_at
+		FROM sales
+		WHERE id = $1`, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, SaleNotFoundErr
+	}
+	return &s, errors.Wrapf(err, "error getting sale with id: %d", id)
+}
+
+// ReadNotEnded returns running and upcoming sales ordered by their start
+func (r *SaleRepository) ReadNotEnded(ctx context.Context) ([]*Sale, error) {
+	sales := make([]*Sale, 0)
+	err := r.db.Select(ctx, &sales, `
+		SELECT id, name, percent, product_id, category_id, company_id, starts_at, ends_at, created_at, updated_at
+		FROM sales
+		WHERE ends_at > NOW()
+		ORDER BY starts_at`)
+	return sales, errors.Wrap(err, "error getting sales")
+}
+
+func (r *SaleRepository) ReadAll(ctx context.Context) ([]*Sale, error) {
+	sales := make([]*Sale, 0)
+	err := r.db.Select(ctx, &sales, `
+		SELECT id, name, percent, product_id, category_id, company_id, starts_at, ends_at, created_at, updated_at
+		FROM sales
+		ORDER BY starts_at DESC`)
+	return sales, errors.Wrap(err, "error getting sales")
+}
+
+func (r *SaleRepository) Update(ctx context.Context, sale *Sale) (bool, error) {
+	sale.UpdatedAt = time.Now().UTC()
+	result, err := r.db.Exec(ctx, `
+		UPDATE sales
+		SET name = $1, percent = $2, product_id = $3, category_id = $4, company_id = $5, starts_at = $6, ends_at = $7, updated_at = $8
+		WHERE id = $9`,
+		sale.Name, sale.Percent, sale.ProductID, sale.CategoryID, sale.CompanyID, sale.StartsAt, sale.EndsAt, sale.UpdatedAt, sale.ID)
+	if repository.IsForeignKeyViolation(err) {
+		return false, SaleTargetNotFoundErr
+	}
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating sale: %v", sale)
+}
+
+func (r *SaleRepository) Delete(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM sales WHERE id = $1", id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting sale with id: %d", id)
+}
@@ -0,0 +1,93 @@
+package sale
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context, sale *Sale) (uint64, error)
+	Read(ctx context.Context, id uint64) (*Sale, error)
+	ReadNotEnded(ctx context.Context) ([]*Sale, error)
+	ReadAll(ctx context.Context) ([]*Sale, error)
+	Update(ctx context.Context, sale *Sale) (bool, error)
+	Delete(ctx context.Context, id uint64) (bool, error)
+}
+
+type SaleService struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *SaleService {
+	return &SaleService{repository: repository}
+}
+
+func (s *SaleService) Create(c echo.Context, saleDTO *DTO) (uint64, error) {
+	id, err := s.repository.Create(c.Request().Context(), saleDTO.ToSale())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *SaleService) Read(c echo.Context, id uint64) (*DTO, error) {
+	sale, err := s.repository.Read(c.Request().Context(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sale.ToDTO(), nil
+}
+
+// ReadNotEnded returns sales running now and the upcoming ones, ended sales are hidden without any cleanup
+func (s *SaleService) ReadNotEnded(c echo.Context) ([]*DTO, error) {
+	sales, err := s.repository.ReadNotEnded(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sales) == 0 {
+		return nil, SaleNotFoundErr
+	}
+
+	return ToDTOs(sales), nil
+}
+
+func (s *SaleService) ReadAll(c echo.Context) ([]*DTO, error) {
+	sales, err := s.repository.ReadAll(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sales) == 0 {
+		return nil, SaleNotFoundErr
+	}
+
+	return ToDTOs(sales), nil
+}
+
+func (s *SaleService) Update(c echo.Context, saleDTO *DTO) (bool, error) {
+	isUpdated, err := s.repository.Update(c.Request().Context(), saleDTO.ToSale())
+
+	if err != nil {
+		return false, err
+	}
+
+	return isUpdated, nil
+}
+
+func (s *SaleService) Delete(c echo.Context, id uint64) (bool, error) {
+	isDeleted, err := s.repository.Delete(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isDeleted, nil
+}
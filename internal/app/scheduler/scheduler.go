@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs the job in the background every interval until the context is done.
+// Failed runs are logged and the job is tried again on the next tick
+func Every(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil {
+					log.Printf("scheduled job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
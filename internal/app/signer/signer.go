@@ -0,0 +1,55 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Signer issues tokens binding ids to a secret, so the ids can be given to clients without letting them
+// forge tokens for other ids. The purpose is signed along with the id, so tokens of one purpose don't work for another
+type Signer struct {
+	secret  []byte
+	purpose string
+}
+
+func New(secret, purpose string) *Signer {
+	return &Signer{secret: []byte(secret), purpose: purpose}
+}
+
+func (s *Signer) sign(id uint64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(s.purpose + strconv.FormatUint(id, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Token returns the id and the signature of the id
+func (s *Signer) Token(id uint64) string {
+	return strconv.FormatUint(id, 10) + "." + s.sign(id)
+}
+
+// Verify returns the id the token was issued for
+func (s *Signer) Verify(token string) (uint64, bool) {
+	idString, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(idString, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+
+	expected, err := hex.DecodeString(s.sign(id))
+	if err != nil {
+		return 0, false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, actual) {
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,41 @@
+package signer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignerVerify(t *testing.T) {
+	s := New("secret", "")
+	token := s.Token(42)
+	id, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		wantID uint64
+		wantOK bool
+	}{
+		{name: "valid token", token: token, wantID: 42, wantOK: true},
+		{name: "other id", token: "43." + signature},
+		{name: "tampered signature", token: id + "." + strings.Repeat("0", len(signature))},
+		{name: "truncated signature", token: token[:len(token)-2]},
+		{name: "uppercase signature", token: id + "." + strings.ToUpper(signature), wantID: 42, wantOK: true},
+		{name: "token of other purpose", token: New("secret", "cart-reminders:").Token(42)},
+		{name: "token of other secret", token: New("other", "").Token(42)},
+		{name: "zero id", token: s.Token(0)},
+		{name: "negative id", token: "-42." + signature},
+		{name: "without signature", token: "42"},
+		{name: "signature not in hex", token: id + ".signature"},
+		{name: "empty", token: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, gotOK := s.Verify(tt.token)
+			if gotID != tt.wantID || gotOK != tt.wantOK {
+				t.Errorf("Verify(%q) = %d, %v, want %d, %v", tt.token, gotID, gotOK, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
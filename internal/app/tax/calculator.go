@@ -0,0 +1,100 @@
+package tax
+
+import (
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+)
+
+// maxRate is 100% in hundredths of a percent
+const maxRate = 10000
+
+// resolveRate returns the most specific rate applied to products of the category, nil when the products aren't taxed
+func resolveRate(rates []*Rate, categoryID uint64) *Rate {
+	var resolved *Rate
+	for _, rate := range rates {
+		if rate.appliesTo(categoryID) && (resolved == nil || rate.specificity() > resolved.specificity()) {
+			resolved = rate
+		}
+	}
+	return resolved
+}
+
+// calculate groups the order items by their rate and taxes every group separately. The order discount and the bundle savings
+// are split between the groups in proportion to their amounts, rounded down, with the remaining kopecks going to the largest group.
+// Tax of a group is rounded half up to whole kopecks: taken from the base when prices include taxes, added to it otherwise
+func calculate(o *order.Order, rates []*Rate, inclusive bool) []*order.TaxLine {
+	var groups []*Rate
+	amounts := make(map[uint64]uint64)
+	var total uint64
+
+	for _, item := range o.OrderItems {
+		if item.Product == nil || item.Product.Category == nil || item.Quantity <= 0 {
+			continue
+		}
+		amount := uint64(item.Quantity) * item.Price
+		total += amount
+
+		rate := resolveRate(rates, item.Product.Category.ID)
+		if rate == nil {
+			continue
+		}
+		if _, ok := amounts[rate.ID]; !ok {
+			groups = append(groups, rate)
+		}
+		amounts[rate.ID] += amount
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	discounts := allocateDiscount(groups, amounts, total, o.Discount+o.BundleDiscount())
+
+	lines := make([]*order.TaxLine, 0, len(groups))
+	for _, rate := range groups {
+		base := amounts[rate.ID] - discounts[rate.ID]
+		lines = append(lines, &order.TaxLine{
+			OrderID: o.ID,
+			Name:    rate.Name,
+			Rate:    rate.Rate,
+			Base:    base,
+			Amount:  taxOf(base, rate.Rate, inclusive),
+		})
+	}
+	return lines
+}
+
+// allocateDiscount splits the discount between the groups of taxed items, untaxed items get their share as well
+func allocateDiscount(groups []*Rate, amounts map[uint64]uint64, total, discount uint64) map[uint64]uint64 {
+	discounts := make(map[uint64]uint64, len(groups))
+	if discount == 0 || total == 0 {
+		return discounts
+	}
+	if discount > total {
+		discount = total
+	}
+
+	var allocated, taxed uint64
+	var largest *Rate
+	for _, rate := range groups {
+		discounts[rate.ID] = amounts[rate.ID] * discount / total
+		allocated += discounts[rate.ID]
+		taxed += amounts[rate.ID]
+		if largest == nil || amounts[rate.ID] > amounts[largest.ID] {
+			largest = rate
+		}
+	}
+
+	// the remainder is only owed by taxed items when every item of the order is taxed
+	if taxed == total {
+		discounts[largest.ID] += discount - allocated
+	}
+	return discounts
+}
+
+func taxOf(base, rate uint64, inclusive bool) uint64 {
+	if inclusive {
+		// base * rate / (100% + rate) rounded half up
+		return (2*base*rate + maxRate + rate) / (2 * (maxRate + rate))
+	}
+	return (base*rate + maxRate/2) / maxRate
+}
@@ -0,0 +1,164 @@
+package tax
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/category"
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/Mickey327/rcsp-backend/internal/app/orderItem"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+)
+
+func categoryID(id uint64) *uint64 {
+	return &id
+}
+
+func TestResolveRate(t *testing.T) {
+	general := &Rate{ID: 1, Rate: 1000}
+	regional := &Rate{ID: 2, Rate: 1500, Region: "EU"}
+	books := &Rate{ID: 3, Rate: 500, CategoryID: categoryID(1)}
+
+	tests := []struct {
+		name       string
+		rates      []*Rate
+		categoryID uint64
+		want       *Rate
+	}{
+		{name: "no rates", categoryID: 1},
+		{name: "general rate", rates: []*Rate{general}, categoryID: 2, want: general},
+		{name: "region beats general", rates: []*Rate{general, regional}, categoryID: 2, want: regional},
+		{name: "category beats region", rates: []*Rate{regional, books, general}, categoryID: 1, want: books},
+		{name: "rate of other category", rates: []*Rate{books}, categoryID: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveRate(tt.rates, tt.categoryID); got != tt.want {
+				t.Errorf("resolveRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      uint64
+		rate      uint64
+		inclusive bool
+		want      uint64
+	}{
+		{name: "added", base: 1000, rate: 2000, want: 200},
+		{name: "added rounds half up", base: 2, rate: 2500, want: 1},
+		{name: "added rounds down", base: 1, rate: 2000, want: 0},
+		{name: "included", base: 12000, rate: 2000, inclusive: true, want: 2000},
+		{name: "included rounds half up", base: 3, rate: 2000, inclusive: true, want: 1},
+		{name: "included rounds up", base: 100, rate: 2000, inclusive: true, want: 17},
+		{name: "zero rate", base: 1000, rate: 0, inclusive: true, want: 0},
+		{name: "zero base", base: 0, rate: 2000, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taxOf(tt.base, tt.rate, tt.inclusive); got != tt.want {
+				t.Errorf("taxOf() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	general := &Rate{ID: 1, Name: "НДС 10%", Rate: 1000}
+	books := &Rate{ID: 2, Name: "НДС 20%", Rate: 2000, CategoryID: categoryID(1)}
+
+	// 2 books of 3000 and a pen of 4000
+	items := []*orderItem.OrderItem{
+		{Product: &product.Product{Category: &category.Category{ID: 1}}, Quantity: 2, Price: 3000},
+		{Product: &product.Product{Category: &category.Category{ID: 2}}, Quantity: 1, Price: 4000},
+		{Product: &product.Product{Category: &category.Category{ID: 2}}, Quantity: 0, Price: 4000},
+	}
+
+	tests := []struct {
+		name      string
+		order     *order.Order
+		rates     []*Rate
+		inclusive bool
+		want      []*order.TaxLine
+	}{
+		{
+			name:  "no rates",
+			order: &order.Order{Total: 10000, OrderItems: items},
+		},
+		{
+			name:      "included",
+			order:     &order.Order{Total: 10000, OrderItems: items},
+			rates:     []*Rate{general, books},
+			inclusive: true,
+			want: []*order.TaxLine{
+				{Name: "НДС 20%", Rate: 2000, Base: 6000, Amount: 1000},
+				{Name: "НДС 10%", Rate: 1000, Base: 4000, Amount: 364},
+			},
+		},
+		{
+			name:  "added",
+			order: &order.Order{Total: 10000, OrderItems: items},
+			rates: []*Rate{general, books},
+			want: []*order.TaxLine{
+				{Name: "НДС 20%", Rate: 2000, Base: 6000, Amount: 1200},
+				{Name: "НДС 10%", Rate: 1000, Base: 4000, Amount: 400},
+			},
+		},
+		{
+			name:  "discount remainder goes to largest group",
+			order: &order.Order{Total: 10000, Discount: 1001, OrderItems: items},
+			rates: []*Rate{general, books},
+			want: []*order.TaxLine{
+				{Name: "НДС 20%", Rate: 2000, Base: 5399, Amount: 1080},
+				{Name: "НДС 10%", Rate: 1000, Base: 3600, Amount: 360},
+			},
+		},
+		{
+			name:  "bundle savings are split like discount",
+			order: &order.Order{Total: 9000, OrderItems: items},
+			rates: []*Rate{general, books},
+			want: []*order.TaxLine{
+				{Name: "НДС 20%", Rate: 2000, Base: 5400, Amount: 1080},
+				{Name: "НДС 10%", Rate: 1000, Base: 3600, Amount: 360},
+			},
+		},
+		{
+			name:  "untaxed items keep their share of discount",
+			order: &order.Order{Total: 10000, Discount: 1001, OrderItems: items},
+			rates: []*Rate{books},
+			want: []*order.TaxLine{
+				{Name: "НДС 20%", Rate: 2000, Base: 5400, Amount: 1080},
+			},
+		},
+		{
+			name:  "discount above total is capped",
+			order: &order.Order{Total: 10000, Discount: 20000, OrderItems: items},
+			rates: []*Rate{general, books},
+			want: []*order.TaxLine{
+				{Name: "НДС 20%", Rate: 2000, Base: 0, Amount: 0},
+				{Name: "НДС 10%", Rate: 1000, Base: 0, Amount: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculate(tt.order, tt.rates, tt.inclusive); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calculate() = %v, want %v", lines(got), lines(tt.want))
+			}
+		})
+	}
+}
+
+func lines(taxLines []*order.TaxLine) []order.TaxLine {
+	var values []order.TaxLine
+	for _, line := range taxLines {
+		values = append(values, *line)
+	}
+	return values
+}
@@ -0,0 +1,31 @@
+package tax
+
+import "strings"
+
+type DTO struct {
+	ID         uint64  `json:"id,omitempty"`
+	Name       string  `json:"name"`
+	Rate       uint64  `json:"rate"`
+	CategoryID *uint64 `json:"category_id,omitempty"`
+	Region     string  `json:"region,omitempty"`
+}
+
+func (d *DTO) ToRate() *Rate {
+	return &Rate{
+		ID:         d.ID,
+		Name:       d.Name,
+		Rate:       d.Rate,
+		CategoryID: d.CategoryID,
+		Region:     NormalizeRegion(d.Region),
+	}
+}
+
+// IsValid reports whether the rate is named and lies between 0% and 100%
+func (d *DTO) IsValid() bool {
+	return d.Name != "" && d.Rate <= maxRate
+}
+
+// NormalizeRegion makes regions typed by customers and admins comparable
+func NormalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
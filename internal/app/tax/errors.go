@@ -0,0 +1,9 @@
+package tax
+
+import "errors"
+
+var (
+	TaxRateNotFoundErr      = errors.New("налоговая ставка не найдена")
+	TaxRateAlreadyExistsErr = errors.New("налоговая ставка для этой категории и региона уже существует")
+	TaxCategoryNotFoundErr  = errors.New("категория налоговой ставки не найдена")
+)
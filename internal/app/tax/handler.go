@@ -0,0 +1,104 @@
+package tax
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Create(c echo.Context, rateDTO *DTO) (uint64, error)
+	ReadAll(c echo.Context) ([]*DTO, error)
+	Delete(c echo.Context, id uint64) (bool, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	rateDTO := DTO{}
+
+	if err = c.Bind(&rateDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	if !rateDTO.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+
+	id, err := h.service.Create(c, &rateDTO)
+	if err != nil {
+		if errors.Is(err, TaxRateAlreadyExistsErr) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if errors.Is(err, TaxCategoryNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка создания налоговой ставки")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"id":      id,
+		"message": "налоговая ставка была успешно создана",
+	})
+}
+
+func (h *Handler) ReadAll(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	rateDTOs, err := h.service.ReadAll(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, TaxRateNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"rates": rateDTOs,
+	})
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	_, err := auth.GetUserDataAndCheckRole(c, "admin")
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "ошибка парсинга id налоговой ставки")
+	}
+	if id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id налоговой ставки должно быть положительным")
+	}
+
+	isDeleted, err := h.service.Delete(c, id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !isDeleted {
+		return echo.NewHTTPError(http.StatusNotFound, TaxRateNotFoundErr.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "налоговая ставка была успешно удалена",
+	})
+}
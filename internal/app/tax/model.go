@@ -0,0 +1,51 @@
+package tax
+
+import "time"
+
+// Rate taxes products of the category, or every product when the category is empty, sold to customers of the region,
+// or to every customer when the region is empty. Rate is given in hundredths of a percent, 2000 is 20%
+type Rate struct {
+	ID         uint64    `db:"id"`
+	Name       string    `db:"name"`
+	Rate       uint64    `db:"rate"`
+	CategoryID *uint64   `db:"category_id"`
+	Region     string    `db:"region"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// specificity orders rates matching the same item, a rate of the category beats a rate of the region
+func (r *Rate) specificity() int {
+	s := 0
+	if r.CategoryID != nil {
+		s += 2
+	}
+	if r.Region != "" {
+		s++
+	}
+	return s
+}
+
+func (r *Rate) appliesTo(categoryID uint64) bool {
+	return r.CategoryID == nil || *r.CategoryID == categoryID
+}
+
+func (r *Rate) ToDTO() *DTO {
+	return &DTO{
+		ID:         r.ID,
+		Name:       r.Name,
+		Rate:       r.Rate,
+		CategoryID: r.CategoryID,
+		Region:     r.Region,
+	}
+}
+
+func ToDTOs(rates []*Rate) []*DTO {
+	var rateDTOs []*DTO
+
+	for _, rate := range rates {
+		rateDTOs = append(rateDTOs, rate.ToDTO())
+	}
+
+	return rateDTOs
+}
@@ -0,0 +1,73 @@
+package tax
+
+import (
+	"context"
+
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type TaxRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *TaxRepository {
+	return &TaxRepository{db: db}
+}
+
+func (r *TaxRepository) Create(ctx context.Context, rate *Rate) (uint64, error) {
+	var id uint64
+	err := r.db.ExecQueryRow(ctx, `
+		INSERT INTO tax_rates(name, rate, category_id, region)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		rate.Name, rate.Rate, rate.CategoryID, rate.Region).Scan(&id)
+	if repository.IsUniqueViolation(err) {
+		return 0, TaxRateAlreadyExistsErr
+	}
+	if repository.IsForeignKeyViolation(err) {
+		return 0, TaxCategoryNotFoundErr
+	}
+	return id, errors.Wrapf(err, "error creating tax rate: %v", rate)
+}
+
+func (r *TaxRepository) ReadAll(ctx context.Context) ([]*Rate, error) {
+	rates := make([]*Rate, 0)
+	err := r.db.Select(ctx, &rates, `
+		SELECT id, name, rate, category_id, region, created_at, updated_at
+		FROM tax_rates
+		ORDER BY region, category_id NULLS FIRST, id`)
+	return rates, errors.Wrap(err, "error getting tax rates")
+}
+
+// ReadForRegion returns rates of the region and the rates applied everywhere
+func (r *TaxRepository) ReadForRegion(ctx context.Context, region string) ([]*Rate, error) {
+	rates := make([]*Rate, 0)
+	err := r.db.Select(ctx, &rates, `
+		SELECT id, name, rate, category_id, region, created_at, updated_at
+		FROM tax_rates
+		WHERE region = '' OR region = $1
+		ORDER BY id`, region)
+	return rates, errors.Wrapf(err, "error getting tax rates of region: %s", region)
+}
+
+func (r *TaxRepository) ReadUserRegion(ctx context.Context, userID uint64) (string, error) {
+	var region string
+	err := r.db.ExecQueryRow(ctx, "SELECT region FROM users WHERE id = $1", userID).Scan(&region)
+	return region, errors.Wrapf(err, "error getting region of user with id: %d", userID)
+}
+
+func (r *TaxRepository) Delete(ctx context.Context, id uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM tax_rates WHERE id = $1", id)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error deleting tax rate with id: %d", id)
+}
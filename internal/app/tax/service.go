@@ -0,0 +1,75 @@
+package tax
+
+import (
+	"context"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/order"
+	"github.com/labstack/echo/v4"
+)
+
+type Repository interface {
+	Create(ctx context.Context, rate *Rate) (uint64, error)
+	ReadAll(ctx context.Context) ([]*Rate, error)
+	ReadForRegion(ctx context.Context, region string) ([]*Rate, error)
+	ReadUserRegion(ctx context.Context, userID uint64) (string, error)
+	Delete(ctx context.Context, id uint64) (bool, error)
+}
+
+type TaxService struct {
+	repository Repository
+	inclusive  bool
+}
+
+// NewService creates the service taxing orders, inclusive tells whether product prices already include taxes
+func NewService(repository Repository, inclusive bool) *TaxService {
+	return &TaxService{repository: repository, inclusive: inclusive}
+}
+
+func (s *TaxService) Create(c echo.Context, rateDTO *DTO) (uint64, error) {
+	id, err := s.repository.Create(c.Request().Context(), rateDTO.ToRate())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *TaxService) ReadAll(c echo.Context) ([]*DTO, error) {
+	rates, err := s.repository.ReadAll(c.Request().Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rates) == 0 {
+		return nil, TaxRateNotFoundErr
+	}
+
+	return ToDTOs(rates), nil
+}
+
+func (s *TaxService) Delete(c echo.Context, id uint64) (bool, error) {
+	isDeleted, err := s.repository.Delete(c.Request().Context(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return isDeleted, nil
+}
+
+// Calculate returns tax lines of the order loaded with its items and products, rates depend on the region of the customer
+func (s *TaxService) Calculate(ctx context.Context, o *order.Order) ([]*order.TaxLine, bool, error) {
+	region, err := s.repository.ReadUserRegion(ctx, o.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rates, err := s.repository.ReadForRegion(ctx, region)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return calculate(o, rates, s.inclusive), s.inclusive, nil
+}
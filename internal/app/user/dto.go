@@ -5,6 +5,12 @@ type DTO struct {
 	Email    string `json:"email,omitempty" query:"email" validate:"required,email"`
 	Password string `json:"password,omitempty" query:"password" validate:"required,min=5"`
 	Role     string `json:"role,omitempty" query:"role"`
+	Region   string `json:"region,omitempty" query:"region"`
+}
+
+// RegionDTO changes the region of the customer used to pick tax rates
+type RegionDTO struct {
+	Region string `json:"region"`
 }
 
 func (d *DTO) ToUser() *User {
@@ -13,5 +19,6 @@ func (d *DTO) ToUser() *User {
 		Email:    d.Email,
 		Password: d.Password,
 		Role:     d.Role,
+		Region:   d.Region,
 	}
 }
@@ -12,6 +12,7 @@ import (
 type Service interface {
 	Register(c echo.Context, userDTO *DTO) error
 	Login(c echo.Context, userDTO *DTO) (string, error)
+	UpdateRegion(c echo.Context, userID uint64, region string) error
 }
 
 type Handler struct {
@@ -112,3 +113,28 @@ func (h *Handler) Logout(c echo.Context) error {
 		"message": "пользователь успешно вышел",
 	})
 }
+
+func (h *Handler) UpdateRegion(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	regionDTO := &RegionDTO{}
+
+	if err = c.Bind(regionDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	if err = h.service.UpdateRegion(c, userData.ID, regionDTO.Region); err != nil {
+		if errors.Is(err, UserNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка обновления региона пользователя")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "регион пользователя успешно обновлен",
+	})
+}
@@ -7,6 +7,7 @@ type User struct {
 	Email     string    `db:"email"`
 	Password  string    `db:"password"`
 	Role      string    `db:"role_name"`
+	Region    string    `db:"region"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
@@ -17,5 +18,6 @@ func (u *User) ToDTO() *DTO {
 		Email:    u.Email,
 		Password: u.Password,
 		Role:     u.Role,
+		Region:   u.Region,
 	}
 }
@@ -15,6 +15,7 @@ type DB interface {
 	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
 	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
 	GetPool() *pgxpool.Pool
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type UserRepository struct {
@@ -27,8 +28,14 @@ func NewRepository(db DB) *UserRepository {
 
 func (u *UserRepository) Register(ctx context.Context, user *User) (uint64, error) {
 	var id uint64
-	err := u.db.ExecQueryRow(ctx, `INSERT INTO users(email, password, role_name) VALUES ($1, $2, $3) RETURNING id`, user.Email, user.Password, user.Role).Scan(&id)
-	err = u.db.ExecQueryRow(ctx, `INSERT INTO orders(status, user_id) VALUES ('Создан', $1) RETURNING id`, id).Scan(&id)
+	err := u.db.WithTx(ctx, func(ctx context.Context) error {
+		err := u.db.ExecQueryRow(ctx, `INSERT INTO users(email, password, role_name, region) VALUES ($1, $2, $3, $4) RETURNING id`, user.Email, user.Password, user.Role, user.Region).Scan(&id)
+		if err != nil {
+			return err
+		}
+		_, err = u.db.Exec(ctx, `INSERT INTO orders(status, user_id) VALUES ('Создан', $1)`, id)
+		return err
+	})
 	return id, errors.Wrapf(err, "error registering user: %v", user)
 }
 
@@ -40,3 +47,8 @@ func (u *UserRepository) GetByEmail(ctx context.Context, user *User) (*User, err
 	}
 	return &dbUser, nil
 }
+
+func (u *UserRepository) UpdateRegion(ctx context.Context, userID uint64, region string) (bool, error) {
+	result, err := u.db.Exec(ctx, "UPDATE users SET region = $1, updated_at = NOW() WHERE id = $2", region, userID)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error updating region of user with id: %d", userID)
+}
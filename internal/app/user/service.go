@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/tax"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -12,19 +13,27 @@ import (
 type Repository interface {
 	Register(ctx context.Context, user *User) (uint64, error)
 	GetByEmail(ctx context.Context, user *User) (*User, error)
+	UpdateRegion(ctx context.Context, userID uint64, region string) (bool, error)
+}
+
+// GuestCarts moves the cart built before logging in into the user's cart
+type GuestCarts interface {
+	Merge(c echo.Context, userID uint64) error
 }
 
 type UserService struct {
 	repository Repository
+	guestCarts GuestCarts
 }
 
-func NewService(repository Repository) *UserService {
-	return &UserService{repository: repository}
+func NewService(repository Repository, guestCarts GuestCarts) *UserService {
+	return &UserService{repository: repository, guestCarts: guestCarts}
 }
 
 // Register creates new user (with 'user' role)
 func (u *UserService) Register(c echo.Context, userDTO *DTO) error {
 	userDTO.Role = "user" //by default user has 'user' role.
+	userDTO.Region = tax.NormalizeRegion(userDTO.Region)
 
 	password, err := bcrypt.GenerateFromPassword([]byte(userDTO.Password), 10)
 	if err != nil {
@@ -40,6 +49,8 @@ func (u *UserService) Register(c echo.Context, userDTO *DTO) error {
 	}
 	userDTO.ID = id
 
+	u.mergeGuestCart(c, id)
+
 	return nil
 }
 
@@ -69,5 +80,28 @@ func (u *UserService) Login(c echo.Context, userDTO *DTO) (string, error) {
 		return "", UserTokenErr
 	}
 
+	u.mergeGuestCart(c, user.ID)
+
 	return token, nil
 }
+
+// mergeGuestCart keeps the guest cart when it can't be merged, so failures don't prevent logging in
+func (u *UserService) mergeGuestCart(c echo.Context, userID uint64) {
+	if err := u.guestCarts.Merge(c, userID); err != nil {
+		log.Printf("error merging guest cart into cart of user %d: %v", userID, err)
+	}
+}
+
+// UpdateRegion changes the region of the user, taxes of the orders arranged before aren't recalculated
+func (u *UserService) UpdateRegion(c echo.Context, userID uint64, region string) error {
+	isUpdated, err := u.repository.UpdateRegion(c.Request().Context(), userID, tax.NormalizeRegion(region))
+	if err != nil {
+		return err
+	}
+
+	if !isUpdated {
+		return UserNotFoundErr
+	}
+
+	return nil
+}
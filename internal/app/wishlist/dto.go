@@ -0,0 +1,28 @@
+package wishlist
+
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+)
+
+type ItemDTO struct {
+	Product   *product.DTO `json:"product"`
+	Notify    bool         `json:"notify"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// AddDTO adds the product to the wishlist, alerts about the product are on unless turned off
+type AddDTO struct {
+	ProductID uint64 `json:"product_id"`
+	Notify    *bool  `json:"notify"`
+}
+
+func (d *AddDTO) IsNotify() bool {
+	return d.Notify == nil || *d.Notify
+}
+
+type ShareDTO struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
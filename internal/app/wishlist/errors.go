@@ -0,0 +1,8 @@
+package wishlist
+
+import "errors"
+
+var (
+	WishlistItemNotFoundErr = errors.New("товар не найден в списке желаемого")
+	WishlistNotSharedErr    = errors.New("список желаемого не найден или закрыт владельцем")
+)
@@ -0,0 +1,152 @@
+package wishlist
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/auth"
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	Read(c echo.Context, userID uint64) ([]*ItemDTO, error)
+	Add(c echo.Context, userID uint64, dto *AddDTO) ([]*ItemDTO, error)
+	Remove(c echo.Context, userID, productID uint64) ([]*ItemDTO, error)
+	Share(c echo.Context, userID uint64) (*ShareDTO, error)
+	Unshare(c echo.Context, userID uint64) error
+	ReadShared(c echo.Context, token string) ([]*ItemDTO, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Read(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	itemDTOs, err := h.service.Read(c, userData.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения списка желаемого")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":     http.StatusOK,
+		"wishlist": itemDTOs,
+	})
+}
+
+func (h *Handler) Add(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	addDTO := AddDTO{}
+
+	if err = c.Bind(&addDTO); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка привязки данных из json")
+	}
+
+	if addDTO.ProductID == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "данные представлены в неверном формате")
+	}
+
+	itemDTOs, err := h.service.Add(c, userData.ID, &addDTO)
+	if err != nil {
+		if errors.Is(err, product.ProductNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка добавления товара в список желаемого")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":     http.StatusOK,
+		"wishlist": itemDTOs,
+	})
+}
+
+func (h *Handler) Remove(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	productID, err := strconv.ParseUint(c.Param("productID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "неверный формат id товара")
+	}
+
+	itemDTOs, err := h.service.Remove(c, userData.ID, productID)
+	if err != nil {
+		if errors.Is(err, WishlistItemNotFoundErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка удаления товара из списка желаемого")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":     http.StatusOK,
+		"wishlist": itemDTOs,
+	})
+}
+
+func (h *Handler) Share(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	shareDTO, err := h.service.Share(c, userData.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка создания ссылки на список желаемого")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":  http.StatusOK,
+		"share": shareDTO,
+	})
+}
+
+func (h *Handler) Unshare(c echo.Context) error {
+	userData, err := auth.GetUserDataAndCheckRole(c, "user")
+	if err != nil {
+		return err
+	}
+
+	if err = h.service.Unshare(c, userData.ID); err != nil {
+		if errors.Is(err, WishlistNotSharedErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка закрытия ссылки на список желаемого")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":    http.StatusOK,
+		"message": "ссылка на список желаемого закрыта",
+	})
+}
+
+// ReadShared shows the wishlist by its public link to anyone
+func (h *Handler) ReadShared(c echo.Context) error {
+	itemDTOs, err := h.service.ReadShared(c, c.Param("token"))
+	if err != nil {
+		if errors.Is(err, WishlistNotSharedErr) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "ошибка получения списка желаемого")
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code":     http.StatusOK,
+		"wishlist": itemDTOs,
+	})
+}
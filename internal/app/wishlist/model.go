@@ -0,0 +1,49 @@
+package wishlist
+
+import (
+	"time"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+)
+
+const (
+	AlertPriceDrop   = "price_drop"
+	AlertBackInStock = "back_in_stock"
+)
+
+type Item struct {
+	UserID    uint64           `db:"user_id"`
+	Notify    bool             `db:"notify"`
+	CreatedAt time.Time        `db:"created_at"`
+	Product   *product.Product `scan:"notate"`
+}
+
+func (i *Item) ToDTO() *ItemDTO {
+	return &ItemDTO{
+		Product:   i.Product.ToDTO(),
+		Notify:    i.Notify,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+func ToDTOs(items []*Item) []*ItemDTO {
+	itemDTOs := make([]*ItemDTO, 0, len(items))
+
+	for _, item := range items {
+		itemDTOs = append(itemDTOs, item.ToDTO())
+	}
+
+	return itemDTOs
+}
+
+// Alert tells the user that the wishlisted product got cheaper or is back in stock
+type Alert struct {
+	ID          uint64 `db:"id"`
+	UserID      uint64 `db:"user_id"`
+	Email       string `db:"email"`
+	ProductID   uint64 `db:"product_id"`
+	ProductName string `db:"product_name"`
+	Kind        string `db:"kind"`
+	OldPrice    uint64 `db:"old_price"`
+	Price       uint64 `db:"price"`
+}
@@ -0,0 +1,119 @@
+package wishlist
+
+import (
+	"context"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/product"
+	"github.com/Mickey327/rcsp-backend/internal/db/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type DB interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	GetPool() *pgxpool.Pool
+}
+
+type WishlistRepository struct {
+	db DB
+}
+
+func NewRepository(db DB) *WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+// Add puts the visible product to the wishlist of the user or changes the alerts setting of the product already there
+func (r *WishlistRepository) Add(ctx context.Context, userID, productID uint64, notify bool) error {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO wishlist_items(user_id, product_id, notify)
+		SELECT $1, p.id, $3
+		FROM products p
+			JOIN categories c on p.category_id = c.id AND c.deleted_at IS NULL
+			JOIN companies c2 on p.company_id = c2.id AND c2.deleted_at IS NULL
+		WHERE p.id = $2 AND p.deleted_at IS NULL
+		ON CONFLICT (user_id, product_id) DO UPDATE SET notify = EXCLUDED.notify`, userID, productID, notify)
+	if err != nil {
+		if repository.IsForeignKeyViolation(err) {
+			return product.ProductNotFoundErr
+		}
+		return errors.Wrapf(err, "error adding product %d to wishlist of user with id: %d", productID, userID)
+	}
+	if result.RowsAffected() == 0 {
+		return product.ProductNotFoundErr
+	}
+	return nil
+}
+
+func (r *WishlistRepository) Remove(ctx context.Context, userID, productID uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM wishlist_items WHERE user_id = $1 AND product_id = $2", userID, productID)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error removing product %d from wishlist of user with id: %d", productID, userID)
+}
+
+// ReadByUserID returns the wishlist of the user with current prices and stock, archived products are hidden
+func (r *WishlistRepository) ReadByUserID(ctx context.Context, userID uint64) ([]*Item, error) {
+	items := make([]*Item, 0)
+	err := r.db.Select(ctx, &items, `
+		SELECT wishlist_items.user_id, wishlist_items.notify, wishlist_items.created_at,
+		       p.id as "product.id", p.name as "product.name", p.description as "product.description", p.price as "product.price",
+		       effective_price(p.id, NOW()) as "product.effective_price",
+		       COALESCE(lowest_price(p.id, NOW() - INTERVAL '30 days'), p.price) as "product.lowest_price",
+		       p.stock as "product.stock", available_stock(p.id, NULL) as "product.available", p.image as "product.image",
+		       p.created_at as "product.created_at", p.updated_at as "product.updated_at",
+		       c.id as "product.category.id", c.name as "product.category.name",
+		       c2.id as "product.company.id", c2.name as "product.company.name"
+		FROM wishlist_items
+			JOIN products p on p.id = wishlist_items.product_id AND p.deleted_at IS NULL
+			JOIN categories c on p.category_id = c.id AND c.deleted_at IS NULL
+			JOIN companies c2 on p.company_id = c2.id AND c2.deleted_at IS NULL
+		WHERE wishlist_items.user_id = $1
+		ORDER BY wishlist_items.created_at DESC`, userID)
+	return items, errors.Wrapf(err, "error reading wishlist of user with id: %d", userID)
+}
+
+// Share returns the token of the public link to the wishlist, the token made before is kept
+func (r *WishlistRepository) Share(ctx context.Context, userID uint64, token string) (string, error) {
+	err := r.db.ExecQueryRow(ctx, `
+		INSERT INTO wishlist_shares(user_id, token) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token = wishlist_shares.token
+		RETURNING token`, userID, token).Scan(&token)
+	return token, errors.Wrapf(err, "error sharing wishlist of user with id: %d", userID)
+}
+
+func (r *WishlistRepository) Unshare(ctx context.Context, userID uint64) (bool, error) {
+	result, err := r.db.Exec(ctx, "DELETE FROM wishlist_shares WHERE user_id = $1", userID)
+	return result.RowsAffected() > 0, errors.Wrapf(err, "error unsharing wishlist of user with id: %d", userID)
+}
+
+func (r *WishlistRepository) ReadUserIDByToken(ctx context.Context, token string) (uint64, error) {
+	var userID uint64
+	err := r.db.Get(ctx, &userID, "SELECT user_id FROM wishlist_shares WHERE token = $1", token)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, WishlistNotSharedErr
+	}
+	return userID, errors.Wrap(err, "error reading shared wishlist")
+}
+
+// ReadPendingAlerts returns alerts which haven't been sent yet, oldest first
+func (r *WishlistRepository) ReadPendingAlerts(ctx context.Context, limit uint64) ([]*Alert, error) {
+	alerts := make([]*Alert, 0)
+	err := r.db.Select(ctx, &alerts, `
+		SELECT wishlist_alerts.id, wishlist_alerts.user_id, users.email, wishlist_alerts.product_id, p.name as product_name,
+		       wishlist_alerts.kind, wishlist_alerts.old_price, wishlist_alerts.price
+		FROM wishlist_alerts
+			JOIN users ON users.id = wishlist_alerts.user_id
+			JOIN products p ON p.id = wishlist_alerts.product_id
+		WHERE wishlist_alerts.sent_at IS NULL
+		ORDER BY wishlist_alerts.id
+		LIMIT $1`, limit)
+	return alerts, errors.Wrap(err, "error reading pending wishlist alerts")
+}
+
+func (r *WishlistRepository) MarkAlertSent(ctx context.Context, id uint64) error {
+	_, err := r.db.Exec(ctx, "UPDATE wishlist_alerts SET sent_at = NOW() WHERE id = $1", id)
+	return errors.Wrapf(err, "error marking wishlist alert with id: %d as sent", id)
+}
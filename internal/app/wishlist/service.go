@@ -0,0 +1,164 @@
+package wishlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/Mickey327/rcsp-backend/internal/app/config"
+	"github.com/Mickey327/rcsp-backend/internal/app/currency"
+	"github.com/Mickey327/rcsp-backend/internal/app/mail"
+	"github.com/labstack/echo/v4"
+)
+
+// alertsBatch limits the number of alerts sent in one run of the job
+const alertsBatch = 100
+
+type Repository interface {
+	Add(ctx context.Context, userID, productID uint64, notify bool) error
+	Remove(ctx context.Context, userID, productID uint64) (bool, error)
+	ReadByUserID(ctx context.Context, userID uint64) ([]*Item, error)
+	Share(ctx context.Context, userID uint64, token string) (string, error)
+	Unshare(ctx context.Context, userID uint64) (bool, error)
+	ReadUserIDByToken(ctx context.Context, token string) (uint64, error)
+	ReadPendingAlerts(ctx context.Context, limit uint64) ([]*Alert, error)
+	MarkAlertSent(ctx context.Context, id uint64) error
+}
+
+// Currencies quotes the currency the client asked prices to be shown in
+type Currencies interface {
+	DisplayQuote(ctx context.Context) *currency.Quote
+}
+
+type WishlistService struct {
+	repository Repository
+	currencies Currencies
+}
+
+func NewService(repository Repository, currencies Currencies) *WishlistService {
+	return &WishlistService{
+		repository: repository,
+		currencies: currencies,
+	}
+}
+
+func (s *WishlistService) Read(c echo.Context, userID uint64) ([]*ItemDTO, error) {
+	items, err := s.repository.ReadByUserID(c.Request().Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.display(c, ToDTOs(items)), nil
+}
+
+func (s *WishlistService) Add(c echo.Context, userID uint64, dto *AddDTO) ([]*ItemDTO, error) {
+	if err := s.repository.Add(c.Request().Context(), userID, dto.ProductID, dto.IsNotify()); err != nil {
+		return nil, err
+	}
+
+	return s.Read(c, userID)
+}
+
+func (s *WishlistService) Remove(c echo.Context, userID, productID uint64) ([]*ItemDTO, error) {
+	isRemoved, err := s.repository.Remove(c.Request().Context(), userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isRemoved {
+		return nil, WishlistItemNotFoundErr
+	}
+
+	return s.Read(c, userID)
+}
+
+// Share opens the wishlist by a public link, sharing the wishlist again returns the same link
+func (s *WishlistService) Share(c echo.Context, userID uint64) (*ShareDTO, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, err
+	}
+
+	token, err := s.repository.Share(c.Request().Context(), userID, hex.EncodeToString(bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareDTO{
+		Token: token,
+		URL:   fmt.Sprintf("%s/wishlist/%s", config.GetConfig().OuterClientAddress, token),
+	}, nil
+}
+
+// Unshare closes the public link, the link shared again gets a new token
+func (s *WishlistService) Unshare(c echo.Context, userID uint64) error {
+	isUnshared, err := s.repository.Unshare(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	if !isUnshared {
+		return WishlistNotSharedErr
+	}
+
+	return nil
+}
+
+func (s *WishlistService) ReadShared(c echo.Context, token string) ([]*ItemDTO, error) {
+	userID, err := s.repository.ReadUserIDByToken(c.Request().Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Read(c, userID)
+}
+
+// SendAlerts emails users about price drops and restocks of the products in their wishlists.
+// An alert is marked as sent right after its email, so a failed run continues where it stopped
+func (s *WishlistService) SendAlerts(ctx context.Context) error {
+	alerts, err := s.repository.ReadPendingAlerts(ctx, alertsBatch)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.GetConfig()
+	for _, alert := range alerts {
+		subject, message := alertMessage(alert)
+		message += fmt.Sprintf("\nТовар можете посмотреть по ссылке: %s/product/%d\n", cfg.OuterClientAddress, alert.ProductID)
+		message += fmt.Sprintf("Настроить уведомления можете в списке желаемого: %s/wishlist", cfg.OuterClientAddress)
+
+		m := mail.New(cfg.Email, alert.Email, subject, message)
+		m.SendMail()
+
+		if err = s.repository.MarkAlertSent(ctx, alert.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(alerts) > 0 {
+		log.Printf("sent %d wishlist alerts", len(alerts))
+	}
+	return nil
+}
+
+func alertMessage(alert *Alert) (string, string) {
+	price := currency.Format(alert.Price, currency.Base)
+	if alert.Kind == AlertBackInStock {
+		return fmt.Sprintf("«%s» снова в наличии", alert.ProductName),
+			fmt.Sprintf("Здравствуйте, товар «%s» из вашего списка желаемого снова в наличии по цене %s.", alert.ProductName, price)
+	}
+	return fmt.Sprintf("«%s» подешевел", alert.ProductName),
+		fmt.Sprintf("Здравствуйте, цена товара «%s» из вашего списка желаемого снизилась с %s до %s.",
+			alert.ProductName, currency.Format(alert.OldPrice, currency.Base), price)
+}
+
+// display converts prices of the products to the currency requested by the client
+func (s *WishlistService) display(c echo.Context, itemDTOs []*ItemDTO) []*ItemDTO {
+	q := s.currencies.DisplayQuote(c.Request().Context())
+	for _, itemDTO := range itemDTOs {
+		itemDTO.Product.Convert(q)
+	}
+	return itemDTOs
+}
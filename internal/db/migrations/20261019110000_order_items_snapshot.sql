@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS price BIGINT,
+    ADD COLUMN IF NOT EXISTS product_name TEXT,
+    ADD COLUMN IF NOT EXISTS product_image TEXT;
+
+CREATE OR REPLACE FUNCTION update_total_price() RETURNS TRIGGER AS $$
+DECLARE
+    changed_order_id BIGINT;
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+        changed_order_id = old.order_id;
+    ELSE
+        changed_order_id = new.order_id;
+    END IF;
+
+    UPDATE orders
+    SET total = COALESCE((SELECT sum(oi.quantity * COALESCE(oi.price, p.price))
+                          FROM order_items oi
+                                   INNER JOIN products p on oi.product_id = p.id
+                          WHERE oi.order_id = changed_order_id), 0),
+        updated_at = NOW()
+    WHERE orders.id = changed_order_id;
+
+    IF (TG_OP = 'DELETE') THEN
+        RETURN old;
+    END IF;
+    RETURN new;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE order_items
+SET price = p.price, product_name = p.name, product_image = p.image
+FROM products p, orders o
+WHERE p.id = order_items.product_id AND o.id = order_items.order_id AND o.is_arranged = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_total_price() RETURNS TRIGGER AS $$
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+        UPDATE orders
+        SET total = COALESCE((SELECT sum(quantity*price) as total
+                            from (SELECT * FROM orders) as something
+                                     INNER JOIN order_items oi on something.id = oi.order_id
+                                     INNER JOIN products p on oi.product_id = p.id
+                                     INNER JOIN users u on orders.user_id = u.id
+                            WHERE something.id = old.order_id
+                            GROUP BY user_id),0),
+            updated_at = NOW()
+        WHERE old.order_id = orders.id;
+        RETURN old;
+    ELSIF (TG_OP = 'UPDATE') OR (TG_OP = 'INSERT') THEN
+        UPDATE orders
+        SET total = COALESCE((SELECT sum(quantity*price) as total
+                            from (SELECT * FROM orders) as something
+                                     INNER JOIN order_items oi on something.id = oi.order_id
+                                     INNER JOIN products p on oi.product_id = p.id
+                                     INNER JOIN users u on orders.user_id = u.id
+                            WHERE something.id = new.order_id
+                            GROUP BY user_id),0),
+        updated_at = NOW()
+        WHERE new.order_id = orders.id;
+        RETURN new;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS product_image,
+    DROP COLUMN IF EXISTS product_name,
+    DROP COLUMN IF EXISTS price;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE orders SET status = 'Создан'
+WHERE is_arranged = false AND status <> 'Создан';
+
+UPDATE orders SET status = 'Ожидает оплаты'
+WHERE is_arranged = true AND status NOT IN ('Ожидает оплаты', 'Оплачен', 'Доставлен', 'Отменен', 'Возвращен');
+
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('Создан', 'Ожидает оплаты', 'Оплачен', 'Доставлен', 'Отменен', 'Возвращен'));
+
+CREATE TABLE IF NOT EXISTS order_status_history(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    role_name TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_status_history;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS arranged_at TIMESTAMP;
+
+UPDATE orders
+SET arranged_at = COALESCE((SELECT MIN(h.created_at)
+                            FROM order_status_history h
+                            WHERE h.order_id = orders.id AND h.from_status = 'Создан'), orders.updated_at)
+WHERE is_arranged = true;
+
+CREATE INDEX IF NOT EXISTS orders_user_id_arranged_at_idx ON orders(user_id, arranged_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_user_id_arranged_at_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS arranged_at;
+-- +goose StatementEnd
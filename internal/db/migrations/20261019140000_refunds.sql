@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_total BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD CONSTRAINT orders_refunded_total_check CHECK (refunded_total <= total);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS refunded_quantity BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD CONSTRAINT order_items_refunded_quantity_check CHECK (refunded_quantity <= quantity);
+
+CREATE TABLE IF NOT EXISTS refunds(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('Рассматривается', 'Одобрен', 'Отклонен')),
+    reason TEXT NOT NULL,
+    admin_comment TEXT NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    resolved_at TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS refund_items(
+    refund_id BIGINT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    order_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity BIGINT NOT NULL CHECK (quantity > 0),
+    price BIGINT NOT NULL,
+    PRIMARY KEY(refund_id, product_id),
+    FOREIGN KEY(order_id, product_id) REFERENCES order_items(order_id, product_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refund_items;
+DROP TABLE IF EXISTS refunds;
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_refunded_quantity_check;
+ALTER TABLE order_items DROP COLUMN IF EXISTS refunded_quantity;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_refunded_total_check;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_total;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS payments(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    provider TEXT NOT NULL,
+    intent_id TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL CHECK (status IN ('Ожидает оплаты', 'Оплачен', 'Ошибка')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    refunded_amount BIGINT NOT NULL DEFAULT 0,
+    payment_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT payments_refunded_amount_check CHECK (refunded_amount <= amount)
+);
+
+CREATE INDEX IF NOT EXISTS payments_order_id_idx ON payments(order_id);
+
+CREATE TABLE IF NOT EXISTS payment_events(
+    id TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    type TEXT NOT NULL,
+    intent_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (provider, id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_events;
+DROP TABLE IF EXISTS payments;
+-- +goose StatementEnd
@@ -0,0 +1,116 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS promo_codes(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    discount_type TEXT NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    value BIGINT NOT NULL CHECK (value > 0),
+    min_total BIGINT NOT NULL DEFAULT 0,
+    usage_limit BIGINT,
+    per_user_limit BIGINT,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT promo_codes_percent_check CHECK (discount_type <> 'percent' OR value <= 100)
+);
+
+CREATE TABLE IF NOT EXISTS promo_code_categories(
+    promo_code_id BIGINT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (promo_code_id, category_id)
+);
+
+CREATE TABLE IF NOT EXISTS promo_code_companies(
+    promo_code_id BIGINT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (promo_code_id, company_id)
+);
+
+CREATE TABLE IF NOT EXISTS promo_code_products(
+    promo_code_id BIGINT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (promo_code_id, product_id)
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS promo_code_id BIGINT REFERENCES promo_codes(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD CONSTRAINT orders_discount_check CHECK (discount <= total);
+
+CREATE TABLE IF NOT EXISTS promo_code_usages(
+    promo_code_id BIGINT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    order_id BIGINT NOT NULL PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS promo_code_usages_promo_code_id_idx ON promo_code_usages(promo_code_id, user_id);
+
+CREATE OR REPLACE FUNCTION update_total_price() RETURNS TRIGGER AS $$
+DECLARE
+    changed_order_id BIGINT;
+    new_total BIGINT;
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+        changed_order_id = old.order_id;
+    ELSE
+        changed_order_id = new.order_id;
+    END IF;
+
+    new_total = COALESCE((SELECT sum(oi.quantity * COALESCE(oi.price, p.price))
+                          FROM order_items oi
+                                   INNER JOIN products p on oi.product_id = p.id
+                          WHERE oi.order_id = changed_order_id), 0);
+
+    UPDATE orders
+    SET total = new_total,
+        discount = LEAST(discount, new_total),
+        updated_at = NOW()
+    WHERE orders.id = changed_order_id;
+
+    IF (TG_OP = 'DELETE') THEN
+        RETURN old;
+    END IF;
+    RETURN new;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_total_price() RETURNS TRIGGER AS $$
+DECLARE
+    changed_order_id BIGINT;
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+        changed_order_id = old.order_id;
+    ELSE
+        changed_order_id = new.order_id;
+    END IF;
+
+    UPDATE orders
+    SET total = COALESCE((SELECT sum(oi.quantity * COALESCE(oi.price, p.price))
+                          FROM order_items oi
+                                   INNER JOIN products p on oi.product_id = p.id
+                          WHERE oi.order_id = changed_order_id), 0),
+        updated_at = NOW()
+    WHERE orders.id = changed_order_id;
+
+    IF (TG_OP = 'DELETE') THEN
+        RETURN old;
+    END IF;
+    RETURN new;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS promo_code_usages;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_discount_check;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS promo_code_id;
+DROP TABLE IF EXISTS promo_code_products;
+DROP TABLE IF EXISTS promo_code_companies;
+DROP TABLE IF EXISTS promo_code_categories;
+DROP TABLE IF EXISTS promo_codes;
+-- +goose StatementEnd
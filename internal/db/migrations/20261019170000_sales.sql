@@ -0,0 +1,99 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sales(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    percent INT NOT NULL CHECK (percent > 0 AND percent < 100),
+    product_id BIGINT REFERENCES products(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category_id BIGINT REFERENCES categories(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    company_id BIGINT REFERENCES companies(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT sales_target_check CHECK (num_nonnulls(product_id, category_id, company_id) = 1),
+    CONSTRAINT sales_period_check CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX IF NOT EXISTS sales_period_idx ON sales(starts_at, ends_at);
+
+-- effective_price returns the product price with the biggest discount of the sales running at the time
+-- for the product, its category or its company
+CREATE OR REPLACE FUNCTION effective_price(sale_product_id BIGINT, at TIMESTAMP) RETURNS BIGINT AS $$
+    SELECT p.price * (100 - COALESCE(MAX(s.percent), 0)) / 100
+    FROM products p
+        LEFT JOIN sales s ON (s.product_id = p.id OR s.category_id = p.category_id OR s.company_id = p.company_id)
+            AND s.starts_at <= at AND s.ends_at > at
+    WHERE p.id = sale_product_id
+    GROUP BY p.id, p.price
+$$ LANGUAGE sql STABLE;
+
+-- order_total sums order items at the prices fixed at checkout or at the prices effective now
+CREATE OR REPLACE FUNCTION order_total(total_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT COALESCE(SUM(oi.quantity * COALESCE(oi.price, effective_price(oi.product_id, LOCALTIMESTAMP))), 0)
+    FROM order_items oi
+    WHERE oi.order_id = total_order_id
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION update_total_price() RETURNS TRIGGER AS $$
+DECLARE
+    changed_order_id BIGINT;
+    new_total BIGINT;
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+        changed_order_id = old.order_id;
+    ELSE
+        changed_order_id = new.order_id;
+    END IF;
+
+    new_total = order_total(changed_order_id);
+
+    UPDATE orders
+    SET total = new_total,
+        discount = LEAST(discount, new_total),
+        updated_at = NOW()
+    WHERE orders.id = changed_order_id;
+
+    IF (TG_OP = 'DELETE') THEN
+        RETURN old;
+    END IF;
+    RETURN new;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_total_price() RETURNS TRIGGER AS $$
+DECLARE
+    changed_order_id BIGINT;
+    new_total BIGINT;
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+        changed_order_id = old.order_id;
+    ELSE
+        changed_order_id = new.order_id;
+    END IF;
+
+    new_total = COALESCE((SELECT sum(oi.quantity * COALESCE(oi.price, p.price))
+                          FROM order_items oi
+                                   INNER JOIN products p on oi.product_id = p.id
+                          WHERE oi.order_id = changed_order_id), 0);
+
+    UPDATE orders
+    SET total = new_total,
+        discount = LEAST(discount, new_total),
+        updated_at = NOW()
+    WHERE orders.id = changed_order_id;
+
+    IF (TG_OP = 'DELETE') THEN
+        RETURN old;
+    END IF;
+    RETURN new;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS order_total(BIGINT);
+DROP FUNCTION IF EXISTS effective_price(BIGINT, TIMESTAMP);
+DROP TABLE IF EXISTS sales;
+-- +goose StatementEnd
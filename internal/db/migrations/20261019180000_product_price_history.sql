@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS product_price_history(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    price BIGINT NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS product_price_history_product_id_idx ON product_price_history(product_id, changed_at);
+
+INSERT INTO product_price_history(product_id, price, changed_at)
+SELECT id, price, COALESCE(created_at, NOW()) FROM products;
+
+-- lowest_price returns the lowest price of the product since the time including the price in force at that time
+CREATE OR REPLACE FUNCTION lowest_price(history_product_id BIGINT, since TIMESTAMP) RETURNS BIGINT AS $$
+    SELECT MIN(h.price)
+    FROM product_price_history h
+    WHERE h.product_id = history_product_id
+      AND h.changed_at >= COALESCE((SELECT MAX(changed_at)
+                                    FROM product_price_history
+                                    WHERE product_id = history_product_id AND changed_at <= since), since)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS lowest_price(BIGINT, TIMESTAMP);
+DROP TABLE IF EXISTS product_price_history;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS region TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS tax_rates(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    rate INT NOT NULL CHECK (rate >= 0 AND rate <= 10000),
+    category_id BIGINT REFERENCES categories(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    region TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tax_rates_category_region_idx ON tax_rates(COALESCE(category_id, 0), region);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS tax_total BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT true;
+
+CREATE TABLE IF NOT EXISTS order_taxes(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name TEXT NOT NULL,
+    rate INT NOT NULL,
+    base BIGINT NOT NULL,
+    amount BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_taxes_order_id_idx ON order_taxes(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_taxes;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_total;
+DROP TABLE IF EXISTS tax_rates;
+ALTER TABLE users DROP COLUMN IF EXISTS region;
+-- +goose StatementEnd
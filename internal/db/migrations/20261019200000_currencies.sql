@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- amounts move from whole rubles to kopecks, totals are scaled directly so the trigger is not needed meanwhile
+ALTER TABLE order_items DISABLE TRIGGER update_order_total_price;
+
+UPDATE products SET price = price * 100;
+UPDATE product_price_history SET price = price * 100;
+UPDATE order_items SET price = price * 100 WHERE price IS NOT NULL;
+UPDATE orders
+SET total = total * 100, discount = discount * 100, refunded_total = refunded_total * 100, tax_total = tax_total * 100;
+UPDATE order_taxes SET base = base * 100, amount = amount * 100;
+UPDATE refunds SET amount = amount * 100;
+UPDATE refund_items SET price = price * 100;
+UPDATE payments SET amount = amount * 100, refunded_amount = refunded_amount * 100;
+UPDATE promo_codes SET min_total = min_total * 100;
+UPDATE promo_codes SET value = value * 100 WHERE discount_type = 'fixed';
+
+ALTER TABLE order_items ENABLE TRIGGER update_order_total_price;
+
+-- currency and exchange_rate are locked at checkout, amounts of orders stay in kopecks and are shown converted
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(20, 10) NOT NULL DEFAULT 1 CHECK (exchange_rate > 0);
+
+-- payments are charged in the currency of the order
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE order_items DISABLE TRIGGER update_order_total_price;
+
+UPDATE products SET price = price / 100;
+UPDATE product_price_history SET price = price / 100;
+UPDATE order_items SET price = price / 100 WHERE price IS NOT NULL;
+UPDATE orders
+SET total = total / 100, discount = discount / 100, refunded_total = refunded_total / 100, tax_total = tax_total / 100;
+UPDATE order_taxes SET base = base / 100, amount = amount / 100;
+UPDATE refunds SET amount = amount / 100;
+UPDATE refund_items SET price = price / 100;
+UPDATE payments SET amount = amount / 100, refunded_amount = refunded_amount / 100;
+UPDATE promo_codes SET min_total = min_total / 100;
+UPDATE promo_codes SET value = value / 100 WHERE discount_type = 'fixed';
+
+ALTER TABLE order_items ENABLE TRIGGER update_order_total_price;
+-- +goose StatementEnd
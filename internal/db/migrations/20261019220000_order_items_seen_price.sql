@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- seen_price is the price of the product the customer saw when the item was added or last validated
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS seen_price BIGINT;
+
+UPDATE order_items SET seen_price = effective_price(order_items.product_id, LOCALTIMESTAMP)
+FROM orders
+WHERE orders.id = order_items.order_id AND orders.is_arranged = false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_items DROP COLUMN IF EXISTS seen_price;
+-- +goose StatementEnd
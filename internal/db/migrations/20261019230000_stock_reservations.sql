@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stock_reservations(
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    quantity BIGINT NOT NULL CHECK (quantity >= 0),
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(order_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservations_product_id_expires_at_idx ON stock_reservations(product_id, expires_at);
+
+-- available_stock is the stock of the product without the quantities reserved by active reservations
+-- of carts other than the given one, NULL order counts every reservation
+CREATE OR REPLACE FUNCTION available_stock(stock_product_id BIGINT, except_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT GREATEST(p.stock - COALESCE((
+        SELECT SUM(r.quantity) FROM stock_reservations r
+        WHERE r.product_id = p.id AND r.expires_at > LOCALTIMESTAMP AND r.order_id IS DISTINCT FROM except_order_id
+    ), 0), 0)
+    FROM products p
+    WHERE p.id = stock_product_id
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS available_stock(BIGINT, BIGINT);
+DROP TABLE IF EXISTS stock_reservations;
+-- +goose StatementEnd
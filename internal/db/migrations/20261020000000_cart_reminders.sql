@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS cart_reminders BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- cart_reminders tracks reminders about abandoned carts, so every cart is reminded about once per idle period
+CREATE TABLE IF NOT EXISTS cart_reminders(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    sent_at TIMESTAMP NOT NULL DEFAULT LOCALTIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS cart_reminders_order_id_sent_at_idx ON cart_reminders(order_id, sent_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS cart_reminders;
+ALTER TABLE users DROP COLUMN IF EXISTS cart_reminders;
+-- +goose StatementEnd
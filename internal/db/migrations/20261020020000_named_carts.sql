@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- named carts are arranging orders with a name, such as saved for later items or a gift list.
+-- The arranging order without a name is the active cart, only it can be checked out
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cart_name TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_user_id_cart_name_idx ON orders(user_id, cart_name)
+    WHERE is_arranged = FALSE AND cart_name IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM orders WHERE cart_name IS NOT NULL AND is_arranged = FALSE;
+DROP INDEX IF EXISTS orders_user_id_cart_name_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS cart_name;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- order_gifts holds recipients of the orders bought as gifts, the gift is emailed to the recipient
+-- once the order is paid and its delivery date has come
+CREATE TABLE IF NOT EXISTS order_gifts(
+    order_id BIGINT NOT NULL PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    recipient_email TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    deliver_at TIMESTAMP,
+    status TEXT NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    sent_at TIMESTAMP,
+    opened_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_gifts;
+-- +goose StatementEnd
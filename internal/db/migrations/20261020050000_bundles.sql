@@ -0,0 +1,99 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS bundles(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    price BIGINT NOT NULL CHECK (price > 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bundle_items(
+    bundle_id BIGINT NOT NULL REFERENCES bundles(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    quantity BIGINT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, product_id)
+);
+
+-- order_bundles links bundles to the order items of their products, the bundle price is fixed at checkout
+CREATE TABLE IF NOT EXISTS order_bundles(
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    bundle_id BIGINT NOT NULL REFERENCES bundles(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    quantity BIGINT NOT NULL CHECK (quantity > 0),
+    price BIGINT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (order_id, bundle_id)
+);
+
+-- bundle_discount returns the savings of the complete bundles of the order: the difference between the prices
+-- of the bundle products and the bundle price. Order items are taken by the bundles in the order they were added,
+-- so an item shared by several bundles is discounted once. Bundles removed from the catalog don't discount carts
+CREATE OR REPLACE FUNCTION bundle_discount(discount_order_id BIGINT) RETURNS BIGINT AS $$
+DECLARE
+    line RECORD;
+    sets BIGINT;
+    regular BIGINT;
+    discount BIGINT = 0;
+    taken JSONB = '{}';
+BEGIN
+    FOR line IN
+        SELECT ob.bundle_id, ob.quantity, COALESCE(ob.price, b.price) as price
+        FROM order_bundles ob
+            JOIN bundles b ON b.id = ob.bundle_id
+        WHERE ob.order_id = discount_order_id AND (ob.price IS NOT NULL OR b.deleted_at IS NULL)
+        ORDER BY ob.created_at, ob.bundle_id
+    LOOP
+        SELECT LEAST(line.quantity,
+                     MIN((COALESCE(oi.quantity, 0) - COALESCE((taken ->> bi.product_id::TEXT)::BIGINT, 0)) / bi.quantity)),
+               SUM(bi.quantity * COALESCE(oi.price, effective_price(bi.product_id, LOCALTIMESTAMP)))
+        INTO sets, regular
+        FROM bundle_items bi
+            LEFT JOIN order_items oi ON oi.order_id = discount_order_id AND oi.product_id = bi.product_id
+        WHERE bi.bundle_id = line.bundle_id;
+
+        CONTINUE WHEN sets IS NULL OR sets <= 0;
+
+        discount = discount + sets * GREATEST(regular - line.price, 0);
+
+        SELECT taken || jsonb_object_agg(bi.product_id::TEXT,
+                                         COALESCE((taken ->> bi.product_id::TEXT)::BIGINT, 0) + sets * bi.quantity)
+        INTO taken
+        FROM bundle_items bi
+        WHERE bi.bundle_id = line.bundle_id;
+    END LOOP;
+
+    RETURN discount;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- order_total sums order items at the prices fixed at checkout or at the prices effective now less the bundle savings
+CREATE OR REPLACE FUNCTION order_total(total_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT GREATEST(COALESCE(SUM(oi.quantity * COALESCE(oi.price, effective_price(oi.product_id, LOCALTIMESTAMP))), 0)
+                        - bundle_discount(total_order_id), 0)
+    FROM order_items oi
+    WHERE oi.order_id = total_order_id
+$$ LANGUAGE sql STABLE;
+
+CREATE TRIGGER update_order_bundles_total_price
+    AFTER INSERT OR UPDATE OR DELETE ON order_bundles
+        FOR EACH ROW EXECUTE FUNCTION update_total_price();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_order_bundles_total_price ON order_bundles;
+
+CREATE OR REPLACE FUNCTION order_total(total_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT COALESCE(SUM(oi.quantity * COALESCE(oi.price, effective_price(oi.product_id, LOCALTIMESTAMP))), 0)
+    FROM order_items oi
+    WHERE oi.order_id = total_order_id
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS bundle_discount(BIGINT);
+DROP TABLE IF EXISTS order_bundles;
+DROP TABLE IF EXISTS bundle_items;
+DROP TABLE IF EXISTS bundles;
+-- +goose StatementEnd
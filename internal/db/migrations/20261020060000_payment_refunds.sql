@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payment_refunds records refunds before the provider is called, pending refunds are sent again
+-- with their id as the idempotency key until the provider confirms them
+CREATE TABLE IF NOT EXISTS payment_refunds(
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status TEXT NOT NULL CHECK (status IN ('Ожидает возврата', 'Возвращен')),
+    provider_refund_id TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payment_refunds_pending_idx ON payment_refunds(id) WHERE status = 'Ожидает возврата';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_refunds;
+-- +goose StatementEnd
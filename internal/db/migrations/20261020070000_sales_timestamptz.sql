@@ -0,0 +1,137 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sale bounds become absolute moments, so prices don't depend on the time zone of the session.
+-- The bounds have been stored in UTC
+ALTER TABLE sales
+    ALTER COLUMN starts_at TYPE TIMESTAMPTZ USING starts_at AT TIME ZONE 'UTC',
+    ALTER COLUMN ends_at TYPE TIMESTAMPTZ USING ends_at AT TIME ZONE 'UTC';
+
+DROP FUNCTION IF EXISTS effective_price(BIGINT, TIMESTAMP);
+
+-- effective_price returns the product price with the biggest discount of the sales running at the time
+-- for the product, its category or its company
+CREATE OR REPLACE FUNCTION effective_price(sale_product_id BIGINT, at TIMESTAMPTZ) RETURNS BIGINT AS $$
+    SELECT p.price * (100 - COALESCE(MAX(s.percent), 0)) / 100
+    FROM products p
+        LEFT JOIN sales s ON (s.product_id = p.id OR s.category_id = p.category_id OR s.company_id = p.company_id)
+            AND s.starts_at <= at AND s.ends_at > at
+    WHERE p.id = sale_product_id
+    GROUP BY p.id, p.price
+$$ LANGUAGE sql STABLE;
+
+-- bundle_discount returns the savings of the complete bundles of the order: the difference between the prices
+-- of the bundle products and the bundle price. Order items are taken by the bundles in the order they were added,
+-- so an item shared by several bundles is discounted once. Bundles removed from the catalog don't discount carts
+CREATE OR REPLACE FUNCTION bundle_discount(discount_order_id BIGINT) RETURNS BIGINT AS $$
+DECLARE
+    line RECORD;
+    sets BIGINT;
+    regular BIGINT;
+    discount BIGINT = 0;
+    taken JSONB = '{}';
+BEGIN
+    FOR line IN
+        SELECT ob.bundle_id, ob.quantity, COALESCE(ob.price, b.price) as price
+        FROM order_bundles ob
+            JOIN bundles b ON b.id = ob.bundle_id
+        WHERE ob.order_id = discount_order_id AND (ob.price IS NOT NULL OR b.deleted_at IS NULL)
+        ORDER BY ob.created_at, ob.bundle_id
+    LOOP
+        SELECT LEAST(line.quantity,
+                     MIN((COALESCE(oi.quantity, 0) - COALESCE((taken ->> bi.product_id::TEXT)::BIGINT, 0)) / bi.quantity)),
+               SUM(bi.quantity * COALESCE(oi.price, effective_price(bi.product_id, NOW())))
+        INTO sets, regular
+        FROM bundle_items bi
+            LEFT JOIN order_items oi ON oi.order_id = discount_order_id AND oi.product_id = bi.product_id
+        WHERE bi.bundle_id = line.bundle_id;
+
+        CONTINUE WHEN sets IS NULL OR sets <= 0;
+
+        discount = discount + sets * GREATEST(regular - line.price, 0);
+
+        SELECT taken || jsonb_object_agg(bi.product_id::TEXT,
+                                         COALESCE((taken ->> bi.product_id::TEXT)::BIGINT, 0) + sets * bi.quantity)
+        INTO taken
+        FROM bundle_items bi
+        WHERE bi.bundle_id = line.bundle_id;
+    END LOOP;
+
+    RETURN discount;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- order_total sums order items at the prices fixed at checkout or at the prices effective now less the bundle savings
+CREATE OR REPLACE FUNCTION order_total(total_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT GREATEST(COALESCE(SUM(oi.quantity * COALESCE(oi.price, effective_price(oi.product_id, NOW()))), 0)
+                        - bundle_discount(total_order_id), 0)
+    FROM order_items oi
+    WHERE oi.order_id = total_order_id
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS effective_price(BIGINT, TIMESTAMPTZ);
+
+ALTER TABLE sales
+    ALTER COLUMN starts_at TYPE TIMESTAMP USING starts_at AT TIME ZONE 'UTC',
+    ALTER COLUMN ends_at TYPE TIMESTAMP USING ends_at AT TIME ZONE 'UTC';
+
+CREATE OR REPLACE FUNCTION effective_price(sale_product_id BIGINT, at TIMESTAMP) RETURNS BIGINT AS $$
+    SELECT p.price * (100 - COALESCE(MAX(s.percent), 0)) / 100
+    FROM products p
+        LEFT JOIN sales s ON (s.product_id = p.id OR s.category_id = p.category_id OR s.company_id = p.company_id)
+            AND s.starts_at <= at AND s.ends_at > at
+    WHERE p.id = sale_product_id
+    GROUP BY p.id, p.price
+$$ LANGUAGE sql STABLE;
+
+-- bundle_discount returns the savings of the complete bundles of the order: the difference between the prices
+-- of the bundle products and the bundle price. Order items are taken by the bundles in the order they were added,
+-- so an item shared by several bundles is discounted once. Bundles removed from the catalog don't discount carts
+CREATE OR REPLACE FUNCTION bundle_discount(discount_order_id BIGINT) RETURNS BIGINT AS $$
+DECLARE
+    line RECORD;
+    sets BIGINT;
+    regular BIGINT;
+    discount BIGINT = 0;
+    taken JSONB = '{}';
+BEGIN
+    FOR line IN
+        SELECT ob.bundle_id, ob.quantity, COALESCE(ob.price, b.price) as price
+        FROM order_bundles ob
+            JOIN bundles b ON b.id = ob.bundle_id
+        WHERE ob.order_id = discount_order_id AND (ob.price IS NOT NULL OR b.deleted_at IS NULL)
+        ORDER BY ob.created_at, ob.bundle_id
+    LOOP
+        SELECT LEAST(line.quantity,
+                     MIN((COALESCE(oi.quantity, 0) - COALESCE((taken ->> bi.product_id::TEXT)::BIGINT, 0)) / bi.quantity)),
+               SUM(bi.quantity * COALESCE(oi.price, effective_price(bi.product_id, LOCALTIMESTAMP)))
+        INTO sets, regular
+        FROM bundle_items bi
+            LEFT JOIN order_items oi ON oi.order_id = discount_order_id AND oi.product_id = bi.product_id
+        WHERE bi.bundle_id = line.bundle_id;
+
+        CONTINUE WHEN sets IS NULL OR sets <= 0;
+
+        discount = discount + sets * GREATEST(regular - line.price, 0);
+
+        SELECT taken || jsonb_object_agg(bi.product_id::TEXT,
+                                         COALESCE((taken ->> bi.product_id::TEXT)::BIGINT, 0) + sets * bi.quantity)
+        INTO taken
+        FROM bundle_items bi
+        WHERE bi.bundle_id = line.bundle_id;
+    END LOOP;
+
+    RETURN discount;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- order_total sums order items at the prices fixed at checkout or at the prices effective now less the bundle savings
+CREATE OR REPLACE FUNCTION order_total(total_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT GREATEST(COALESCE(SUM(oi.quantity * COALESCE(oi.price, effective_price(oi.product_id, LOCALTIMESTAMP))), 0)
+                        - bundle_discount(total_order_id), 0)
+    FROM order_items oi
+    WHERE oi.order_id = total_order_id
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
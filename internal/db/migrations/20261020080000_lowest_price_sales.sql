@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- price changes become absolute moments like sale bounds, the history has been written in UTC
+ALTER TABLE product_price_history ALTER COLUMN changed_at TYPE TIMESTAMPTZ USING changed_at AT TIME ZONE 'UTC';
+
+DROP FUNCTION IF EXISTS lowest_price(BIGINT, TIMESTAMP);
+
+-- lowest_price returns the lowest price of the product since the time including the price in force at that time.
+-- Sales which ended since the time count with the base prices in force during them, the sale running now
+-- is the discount compared with the lowest price, so it's left out
+CREATE OR REPLACE FUNCTION lowest_price(history_product_id BIGINT, since TIMESTAMPTZ) RETURNS BIGINT AS $$
+    WITH prices AS (
+        SELECT h.price, h.changed_at as valid_from,
+               LEAD(h.changed_at, 1, 'infinity') OVER (ORDER BY h.changed_at, h.id) as valid_to
+        FROM product_price_history h
+        WHERE h.product_id = history_product_id
+    )
+    SELECT MIN(lowest.price)
+    FROM (
+        SELECT prices.price
+        FROM prices
+        WHERE prices.valid_to > since
+        UNION ALL
+        SELECT prices.price * (100 - s.percent) / 100
+        FROM products p
+            JOIN sales s ON (s.product_id = p.id OR s.category_id = p.category_id OR s.company_id = p.company_id)
+                AND s.ends_at > since AND s.ends_at <= NOW()
+            JOIN prices ON prices.valid_from < s.ends_at AND prices.valid_to > GREATEST(s.starts_at, since)
+        WHERE p.id = history_product_id
+    ) lowest
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS lowest_price(BIGINT, TIMESTAMPTZ);
+
+ALTER TABLE product_price_history ALTER COLUMN changed_at TYPE TIMESTAMP USING changed_at AT TIME ZONE 'UTC';
+
+-- lowest_price returns the lowest price of the product since the time including the price in force at that time
+CREATE OR REPLACE FUNCTION lowest_price(history_product_id BIGINT, since TIMESTAMP) RETURNS BIGINT AS $$
+    SELECT MIN(h.price)
+    FROM product_price_history h
+    WHERE h.product_id = history_product_id
+      AND h.changed_at >= COALESCE((SELECT MAX(changed_at)
+                                    FROM product_price_history
+                                    WHERE product_id = history_product_id AND changed_at <= since), since)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- delivery dates become absolute moments, so gifts aren't sent early or late in sessions of other time zones.
+-- The dates have been stored in UTC
+ALTER TABLE order_gifts ALTER COLUMN deliver_at TYPE TIMESTAMPTZ USING deliver_at AT TIME ZONE 'UTC';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_gifts ALTER COLUMN deliver_at TYPE TIMESTAMP USING deliver_at AT TIME ZONE 'UTC';
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- reservations expire at absolute moments, so their ttl doesn't depend on the time zone of the session.
+-- Expiry times have been written by LOCALTIMESTAMP of the session
+ALTER TABLE stock_reservations ALTER COLUMN expires_at TYPE TIMESTAMPTZ;
+
+-- available_stock is the stock of the product without the quantities reserved by active reservations
+-- of carts other than the given one, NULL order counts every reservation
+CREATE OR REPLACE FUNCTION available_stock(stock_product_id BIGINT, except_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT GREATEST(p.stock - COALESCE((
+        SELECT SUM(r.quantity) FROM stock_reservations r
+        WHERE r.product_id = p.id AND r.expires_at > NOW() AND r.order_id IS DISTINCT FROM except_order_id
+    ), 0), 0)
+    FROM products p
+    WHERE p.id = stock_product_id
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_reservations ALTER COLUMN expires_at TYPE TIMESTAMP;
+
+CREATE OR REPLACE FUNCTION available_stock(stock_product_id BIGINT, except_order_id BIGINT) RETURNS BIGINT AS $$
+    SELECT GREATEST(p.stock - COALESCE((
+        SELECT SUM(r.quantity) FROM stock_reservations r
+        WHERE r.product_id = p.id AND r.expires_at > LOCALTIMESTAMP AND r.order_id IS DISTINCT FROM except_order_id
+    ), 0), 0)
+    FROM products p
+    WHERE p.id = stock_product_id
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- reminders are sent at absolute moments, so idle periods don't depend on the time zone of the session.
+-- Sending times have been written by LOCALTIMESTAMP of the session
+ALTER TABLE cart_reminders ALTER COLUMN sent_at TYPE TIMESTAMPTZ;
+ALTER TABLE cart_reminders ALTER COLUMN sent_at SET DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cart_reminders ALTER COLUMN sent_at TYPE TIMESTAMP;
+ALTER TABLE cart_reminders ALTER COLUMN sent_at SET DEFAULT LOCALTIMESTAMP;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- locked_until is the lease of the running request, a key left without a response after its lease,
+-- e.g. when the server crashed, is taken over by the next request with the same key
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_until;
+-- +goose StatementEnd
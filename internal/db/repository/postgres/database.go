@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
@@ -14,6 +15,29 @@ type Database struct {
 	cluster *pgxpool.Pool
 }
 
+// querier - общий интерфейс пула соединений и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+type hooksKey struct{}
+
+// txHooks are functions to run once the outermost transaction is committed
+type txHooks struct {
+	fns []func(ctx context.Context)
+}
+
+const (
+	maxTxAttempts = 3
+
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
 func New(ctx context.Context, connect string) (*Database, error) {
 	pool, err := pgxpool.New(ctx, connect)
 	if err != nil {
@@ -22,20 +46,106 @@ func New(ctx context.Context, connect string) (*Database, error) {
 	return &Database{cluster: pool}, nil
 }
 
+// WithTx runs fn inside a read committed transaction, see WithTxOptions
+func (db Database) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.WithTxOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// WithTxOptions runs fn inside a transaction which is committed if fn returns nil and rolled back otherwise.
+// The transaction is carried in the context passed to fn, so every query made with it joins the transaction.
+// Nested calls don't start a new transaction but create a savepoint inside the outer one, so a failed
+// nested fn rolls back only its own changes. Top level transaction is restarted when it fails with
+// a serialization failure or a deadlock, so fn must be safe to run several times. Functions registered
+// with AfterCommit run once the top level transaction is committed.
+func (db Database) WithTxOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	if outer, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		savepoint, err := outer.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		hooks, err := runTx(ctx, savepoint, fn)
+		if err != nil {
+			return err
+		}
+		// hooks of the savepoint wait for the outer transaction, they are dropped if it rolls back
+		parent := ctx.Value(hooksKey{}).(*txHooks)
+		parent.fns = append(parent.fns, hooks.fns...)
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		var tx pgx.Tx
+		tx, err = db.cluster.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		var hooks *txHooks
+		if hooks, err = runTx(ctx, tx, fn); err == nil {
+			for _, hook := range hooks.fns {
+				hook(ctx)
+			}
+			return nil
+		}
+		if !isRetryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// AfterCommit runs fn once the outermost transaction of the context is committed, fn is dropped if the transaction
+// rolls back. Outside of a transaction fn runs at once. fn gets the context the transaction was started with
+func (db Database) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(hooksKey{}).(*txHooks); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+	fn(ctx)
+}
+
+func runTx(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) error) (*txHooks, error) {
+	defer tx.Rollback(ctx)
+
+	hooks := &txHooks{}
+	if err := fn(context.WithValue(context.WithValue(ctx, txKey{}, tx), hooksKey{}, hooks)); err != nil {
+		return nil, err
+	}
+
+	return hooks, tx.Commit(ctx)
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode
+}
+
+func (db Database) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.cluster
+}
+
 func (db Database) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return pgxscan.Select(ctx, db.cluster, dest, query, args...)
+	return pgxscan.Select(ctx, db.conn(ctx), dest, query, args...)
 }
 
 func (db Database) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return pgxscan.Get(ctx, db.cluster, dest, query, args...)
+	return pgxscan.Get(ctx, db.conn(ctx), dest, query, args...)
 }
 
 func (db Database) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
-	return db.cluster.Exec(ctx, query, args...)
+	return db.conn(ctx).Exec(ctx, query, args...)
 }
 
 func (db Database) ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
-	return db.cluster.QueryRow(ctx, query, args...)
+	return db.conn(ctx).QueryRow(ctx, query, args...)
 }
 
 func (db Database) GetPool() *pgxpool.Pool {